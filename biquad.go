@@ -0,0 +1,220 @@
+package snd
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// BiquadType is the response of a Biquad filter as defined by the RBJ audio EQ cookbook.
+type BiquadType int
+
+const (
+	BiquadLowPass BiquadType = iota
+	BiquadHighPass
+	BiquadBandPass // constant 0dB peak gain
+	BiquadNotch
+	BiquadAllPass
+	BiquadPeak
+	BiquadLowShelf
+	BiquadHighShelf
+)
+
+// biquad is a single second order section in transposed direct form II.
+type biquad struct {
+	// coefficients normalized by a0
+	b0, b1, b2, a1, a2 float64
+	// delays
+	z1, z2 float64
+}
+
+// set calculates coefficients for typ at angular frequency w0 normalized to sample rate.
+func (bq *biquad) set(typ BiquadType, w0, q float64, gain Decibel) {
+	cos, sin := math.Cos(w0), math.Sin(w0)
+	alpha := sin / (2 * q)
+	A := math.Pow(10, float64(gain)/40)
+	sqA := 2 * math.Sqrt(A) * alpha
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch typ {
+	case BiquadLowPass:
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BiquadHighPass:
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BiquadBandPass:
+		b0, b1, b2 = alpha, 0, -alpha
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BiquadNotch:
+		b0, b1, b2 = 1, -2*cos, 1
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BiquadAllPass:
+		b0, b1, b2 = 1-alpha, -2*cos, 1+alpha
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BiquadPeak:
+		b0, b1, b2 = 1+alpha*A, -2*cos, 1-alpha*A
+		a0, a1, a2 = 1+alpha/A, -2*cos, 1-alpha/A
+	case BiquadLowShelf:
+		b0 = A * ((A + 1) - (A-1)*cos + sqA)
+		b1 = 2 * A * ((A - 1) - (A+1)*cos)
+		b2 = A * ((A + 1) - (A-1)*cos - sqA)
+		a0 = (A + 1) + (A-1)*cos + sqA
+		a1 = -2 * ((A - 1) + (A+1)*cos)
+		a2 = (A + 1) + (A-1)*cos - sqA
+	case BiquadHighShelf:
+		b0 = A * ((A + 1) + (A-1)*cos + sqA)
+		b1 = -2 * A * ((A - 1) + (A+1)*cos)
+		b2 = A * ((A + 1) + (A-1)*cos - sqA)
+		a0 = (A + 1) - (A-1)*cos + sqA
+		a1 = 2 * ((A - 1) - (A+1)*cos)
+		a2 = (A + 1) - (A-1)*cos - sqA
+	}
+
+	bq.b0, bq.b1, bq.b2 = b0/a0, b1/a0, b2/a0
+	bq.a1, bq.a2 = a1/a0, a2/a0
+}
+
+func (bq *biquad) process(x float64) float64 {
+	y := bq.b0*x + bq.z1
+	bq.z1 = bq.b1*x - bq.a1*y + bq.z2
+	bq.z2 = bq.b2*x - bq.a2*y
+	return y
+}
+
+// response returns magnitude of bq at angular frequency w normalized to sample rate.
+func (bq *biquad) response(w float64) float64 {
+	z1 := cmplx.Exp(complex(0, -w))
+	z2 := z1 * z1
+	num := complex(bq.b0, 0) + complex(bq.b1, 0)*z1 + complex(bq.b2, 0)*z2
+	den := 1 + complex(bq.a1, 0)*z1 + complex(bq.a2, 0)*z2
+	return cmplx.Abs(num / den)
+}
+
+// Biquad is a second order IIR filter.
+//
+// Gain only applies to peak and shelf types. Multiple sections of the same
+// response may be cascaded for a steeper rolloff.
+//
+// Frequency and Q may be modulated, in which case coefficients are updated
+// every frame or at an interval set by SetUpdateRate. Changes to frequency,
+// Q and gain are smoothed over ParamSmoothing.
+type Biquad struct {
+	*mono
+	typ  BiquadType
+	freq param
+	q    param
+	gain param
+
+	// frames between coefficient updates and frames since last
+	rate, k int
+	dirty   bool
+
+	secs []biquad
+}
+
+func NewBiquad(typ BiquadType, freq, q float64, gain Decibel, in Sound) *Biquad {
+	sr := in.SampleRate()
+	bq := &Biquad{
+		mono: newmono(in),
+		typ:  typ,
+		freq: newparam(freq, nil, sr),
+		q:    newparam(q, nil, sr),
+		gain: newparam(float64(gain), nil, sr),
+		rate: 1,
+		secs: make([]biquad, 1),
+	}
+	bq.update()
+	return bq
+}
+
+// set calculates coefficients of all sections, clamping hz and q for stability.
+func (bq *Biquad) set(hz, q, gain float64) {
+	sr := bq.in.SampleRate()
+	w0 := Hertz(clamp(hz, 1, 0.49*sr)).Normalized(sr)
+	q = math.Max(q, 0.01)
+	for i := range bq.secs {
+		bq.secs[i].set(bq.typ, w0, q, Decibel(gain))
+	}
+}
+
+// update calculates coefficients from current unmodulated values.
+func (bq *Biquad) update() { bq.set(bq.freq.y, bq.q.y, bq.gain.y) }
+
+func (bq *Biquad) SetType(typ BiquadType) {
+	bq.typ = typ
+	bq.update()
+}
+
+// SetFreq sets cutoff or center frequency in hertz, multiplied by mod if not nil.
+func (bq *Biquad) SetFreq(hz float64, mod Sound) {
+	bq.freq.set(hz, mod)
+	bq.dirty = true
+}
+
+// SetQ sets resonance, multiplied by mod if not nil.
+func (bq *Biquad) SetQ(q float64, mod Sound) {
+	bq.q.set(q, mod)
+	bq.dirty = true
+}
+
+func (bq *Biquad) SetGain(gain Decibel) {
+	bq.gain.set(float64(gain), nil)
+	bq.dirty = true
+}
+
+// SetUpdateRate sets number of frames between coefficient updates while
+// modulated or smoothing, trading accuracy for performance.
+func (bq *Biquad) SetUpdateRate(n int) {
+	if n < 1 {
+		n = 1
+	}
+	bq.rate = n
+}
+
+func (bq *Biquad) Inputs() []Sound {
+	return []Sound{bq.in, bq.freq.mod, bq.q.mod}
+}
+
+// SetSections sets number of cascaded second order sections.
+func (bq *Biquad) SetSections(n int) {
+	if n < 1 {
+		n = 1
+	}
+	bq.secs = make([]biquad, n)
+	bq.update()
+}
+
+// Response returns magnitude of bq at hz for all sections using current coefficients.
+func (bq *Biquad) Response(hz float64) float64 {
+	w := Hertz(hz).Normalized(bq.in.SampleRate())
+	mag := 1.0
+	for i := range bq.secs {
+		mag *= bq.secs[i].response(w)
+	}
+	return mag
+}
+
+func (bq *Biquad) settled() bool {
+	return bq.freq.settled() && bq.q.settled() && bq.gain.settled()
+}
+
+func (bq *Biquad) Prepare(uint64) {
+	for i, x := range bq.in.Samples() {
+		if bq.dirty || !bq.settled() {
+			hz, q, gain := bq.freq.next(i), bq.q.next(i), bq.gain.next(i)
+			if bq.k++; bq.k >= bq.rate || bq.settled() {
+				bq.k, bq.dirty = 0, false
+				bq.set(hz, q, gain)
+			}
+		}
+
+		if bq.off {
+			bq.out[i] = 0
+		} else {
+			for j := range bq.secs {
+				x = bq.secs[j].process(x)
+			}
+			bq.out[i] = x
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package snd
+
+import (
+	"math"
+	"testing"
+
+	"dasa.cc/signal"
+)
+
+func TestBiquadResponse(t *testing.T) {
+	const (
+		f0   = 1000
+		q    = 2
+		gain = Decibel(6)
+		nyq  = DefaultSampleRate / 2
+	)
+	A2 := gain.Amp()
+
+	tests := []struct {
+		typ  BiquadType
+		hz   float64
+		want float64
+	}{
+		{BiquadLowPass, 0, 1},
+		{BiquadLowPass, f0, q},
+		{BiquadLowPass, nyq, 0},
+		{BiquadHighPass, 0, 0},
+		{BiquadHighPass, f0, q},
+		{BiquadHighPass, nyq, 1},
+		{BiquadBandPass, f0, 1},
+		{BiquadBandPass, 0, 0},
+		{BiquadNotch, f0, 0},
+		{BiquadNotch, 0, 1},
+		{BiquadAllPass, 0, 1},
+		{BiquadAllPass, f0, 1},
+		{BiquadAllPass, 5000, 1},
+		{BiquadPeak, f0, A2},
+		{BiquadPeak, 0, 1},
+		{BiquadLowShelf, 0, A2},
+		{BiquadLowShelf, nyq, 1},
+		{BiquadHighShelf, 0, 1},
+		{BiquadHighShelf, nyq, A2},
+	}
+
+	for _, test := range tests {
+		bq := NewBiquad(test.typ, f0, q, gain, newunit())
+		if have := bq.Response(test.hz); !equaleps(have, test.want, 0.001) {
+			t.Errorf("type(%v) at %vHz have %v, want %v", test.typ, test.hz, have, test.want)
+		}
+		bq.SetSections(3)
+		if have, want := bq.Response(test.hz), math.Pow(test.want, 3); !equaleps(have, want, 0.001) {
+			t.Errorf("type(%v) cascade at %vHz have %v, want %v", test.typ, test.hz, have, want)
+		}
+	}
+}
+
+func TestBiquadFilter(t *testing.T) {
+	for _, typ := range []BiquadType{BiquadLowPass, BiquadHighPass, BiquadBandPass, BiquadPeak} {
+		for _, hz := range []float64{250, 1000, 4000} {
+			bq := NewBiquad(typ, 1000, 0.7071, 6, newtone(hz))
+			bq.SetSections(2)
+			want := bq.Response(hz)
+			if have := amplitude(bq, 64, 64); !equaleps(have, want, 0.01) {
+				t.Errorf("type(%v) at %vHz have amplitude %v, want %v", typ, hz, have, want)
+			}
+		}
+	}
+}
+
+func TestBiquadModulation(t *testing.T) {
+	bq := NewBiquad(BiquadLowPass, 1000, 4, 0, newtone(440))
+	lfo := NewLFO(LFOSine, 2000)
+	lfo.SetUnipolar(true)
+	lfo.SetDepth(10, 0.1)
+	bq.SetFreq(1000, lfo)
+	bq.SetQ(4, NewLFO(LFOTriangle, 3))
+	if x := amplitude(bq, 0, 256); math.IsNaN(x) || x > 10 {
+		t.Fatalf("audio-rate modulation unstable with amplitude %v", x)
+	}
+
+	bq = NewBiquad(BiquadPeak, 1000, 1, 12, newunit())
+	bq.SetFreq(4000, nil)
+	bq.SetGain(-6)
+	bq.Prepare(1)
+	if have, want := bq.Response(4000), Decibel(-6).Amp(); have < want+0.1 {
+		t.Fatalf("change was not smoothed, have %v", have)
+	}
+	for n := 2; n < 100; n++ {
+		bq.Prepare(uint64(n))
+	}
+	if have, want := bq.Response(4000), Decibel(-6).Amp(); !equals(have, want) {
+		t.Fatalf("smoothed change have %v, want %v", have, want)
+	}
+}
+
+func BenchmarkBiquadMod(b *testing.B) {
+	bq := NewBiquad(BiquadLowPass, 500, 0.7071, 0, newunit())
+	bq.SetFreq(500, NewOscil(signal.Sine(), 2, nil))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		bq.Prepare(uint64(n))
+	}
+}
+
+func BenchmarkBiquad(b *testing.B) {
+	bq := NewBiquad(BiquadLowPass, 500, 0.7071, 0, newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		bq.Prepare(uint64(n))
+	}
+}
@@ -0,0 +1,304 @@
+package snd
+
+import "time"
+
+// MaxChorusDelay is the greatest delay and depth combined of Chorus voices.
+const MaxChorusDelay = 50 * time.Millisecond
+
+// chorusvoice is a read of shared delay lines modulated by its own lfo.
+type chorusvoice struct {
+	vt   [2]vtime
+	lfo  *LFO
+	l, r float64 // pan gains
+}
+
+// Chorus thickens a signal by mixing copies read from a delay line at slowly
+// modulated times, each voice panned across the stereo field.
+//
+// Channels of a stereo input are delayed separately, keeping their image.
+type Chorus struct {
+	*stereo
+	lines  [2]*bufc
+	voices []*chorusvoice
+
+	delay, depth time.Duration
+	spread       float64
+	wet, dry     float64
+}
+
+// NewChorus returns Chorus of n voices with modulation phases evenly spread.
+func NewChorus(n int, in Sound) *Chorus {
+	if n < 1 {
+		n = 1
+	}
+	sr := in.SampleRate()
+	max := Dtof(MaxChorusDelay, sr)
+	ch := &Chorus{
+		stereo: newstereo(in),
+		lines:  [2]*bufc{newbufc(max+2, 0), newbufc(max+2, 0)},
+		voices: make([]*chorusvoice, n),
+		delay:  15 * time.Millisecond,
+		depth:  3 * time.Millisecond,
+		wet:    1,
+		dry:    1,
+	}
+	for i := range ch.voices {
+		lfo := NewLFO(LFOSine, 0.8)
+		lfo.phase = float64(i) / float64(n)
+		vt := newvtime(ch.delay, max, sr)
+		ch.voices[i] = &chorusvoice{vt: [2]vtime{vt, vt}, lfo: lfo}
+	}
+	ch.SetSpread(1)
+	ch.update()
+	return ch
+}
+
+// SetRate sets modulation rate of all voices in hertz.
+func (ch *Chorus) SetRate(hz float64) {
+	for _, v := range ch.voices {
+		v.lfo.SetFreq(hz)
+	}
+}
+
+// SetSync sets modulation rate of all voices to one cycle per note division div at bpm.
+func (ch *Chorus) SetSync(bpm BPM, div Division) {
+	for _, v := range ch.voices {
+		v.lfo.SetSync(bpm, div)
+	}
+}
+
+// SetDelay sets center delay of voices.
+func (ch *Chorus) SetDelay(d time.Duration) {
+	ch.delay = d
+	ch.update()
+}
+
+// SetDepth sets amount voice delays deviate from center, limited to delay.
+func (ch *Chorus) SetDepth(d time.Duration) {
+	ch.depth = d
+	ch.update()
+}
+
+// SetSpread sets width voices are panned across where spread belongs to [0..1].
+func (ch *Chorus) SetSpread(spread float64) {
+	ch.spread = clamp(spread, 0, 1)
+	n := len(ch.voices)
+	for i, v := range ch.voices {
+		xf := 0.0
+		if n > 1 {
+			xf = ch.spread * (2*float64(i)/float64(n-1) - 1)
+		}
+		v.l, v.r = getpanfac(xf), getpanfac(-xf)
+	}
+}
+
+// SetMix sets amplitude of chorused and original signal.
+func (ch *Chorus) SetMix(wet, dry float64) { ch.wet, ch.dry = wet, dry }
+
+func (ch *Chorus) update() {
+	if ch.delay+ch.depth > MaxChorusDelay {
+		ch.delay = MaxChorusDelay - ch.depth
+	}
+	if ch.depth > ch.delay {
+		ch.depth = ch.delay
+	}
+	amt := 0.0
+	if ch.delay > 0 {
+		amt = float64(ch.depth) / float64(ch.delay)
+	}
+	for _, v := range ch.voices {
+		v.lfo.SetDepth(amt, 1)
+		for c := range v.vt {
+			v.vt[c].SetTime(ch.delay, v.lfo)
+		}
+	}
+}
+
+func (ch *Chorus) Inputs() []Sound {
+	inps := []Sound{ch.in}
+	for _, v := range ch.voices {
+		inps = append(inps, v.lfo)
+	}
+	return inps
+}
+
+func (ch *Chorus) Prepare(uint64) {
+	// a mono input is read from the left line only
+	st := ch.in.Channels() == 2
+	g := ch.wet * onesqrt2 * 2 / float64(len(ch.voices))
+	for i := range ch.l.out {
+		l, r := frame(ch.in, i)
+
+		var wl, wr float64
+		for _, v := range ch.voices {
+			xl := v.vt[0].read(ch.lines[0], ch.lines[0].w, i)
+			xr := xl
+			if st {
+				xr = v.vt[1].read(ch.lines[1], ch.lines[1].w, i)
+			}
+			wl += xl * v.l
+			wr += xr * v.r
+		}
+		ch.lines[0].write(l)
+		if st {
+			ch.lines[1].write(r)
+		}
+
+		if ch.l.off {
+			ch.l.out[i] = 0
+		} else {
+			ch.l.out[i] = l*ch.dry + wl*g
+		}
+		if ch.r.off {
+			ch.r.out[i] = 0
+		} else {
+			ch.r.out[i] = r*ch.dry + wr*g
+		}
+		ch.out[i*2] = ch.l.out[i]
+		ch.out[i*2+1] = ch.r.out[i]
+	}
+}
+
+// Flanger mixes a signal with a copy delayed by a short sweeping time, with
+// feedback producing a series of moving notches and peaks.
+//
+// Through-zero flanging delays the original signal by half the depth so the
+// sweeping copy passes in front of it, cancelling completely at zero.
+type Flanger struct {
+	*mono
+	vt   vtime
+	lfo  *LFO
+	line *bufc
+	tzl  *bufc // delay of original signal for through-zero
+
+	fb       float64
+	tz       bool
+	wet, dry float64
+}
+
+// NewFlanger returns Flanger sweeping 2ms at 0.25Hz with feedback of 0.5.
+func NewFlanger(in Sound) *Flanger {
+	sr := in.SampleRate()
+	max := Dtof(MaxChorusDelay, sr)
+	fl := &Flanger{
+		mono: newmono(in),
+		vt:   newvtime(0, max, sr),
+		lfo:  NewLFO(LFOTriangle, 0.25),
+		line: newbufc(max+2, 0),
+		fb:   0.5,
+		wet:  1,
+		dry:  1,
+	}
+	fl.lfo.SetDepth(0.5, 0.5)
+	fl.SetDepth(2 * time.Millisecond)
+	return fl
+}
+
+// SetRate sets sweep rate in hertz.
+func (fl *Flanger) SetRate(hz float64) { fl.lfo.SetFreq(hz) }
+
+// SetSync sets sweep rate to one cycle per note division div at bpm.
+func (fl *Flanger) SetSync(bpm BPM, div Division) { fl.lfo.SetSync(bpm, div) }
+
+// SetDepth sets greatest delay of sweep, limited to MaxChorusDelay.
+func (fl *Flanger) SetDepth(d time.Duration) {
+	if d > MaxChorusDelay {
+		d = MaxChorusDelay
+	}
+	fl.vt.SetTime(d, fl.lfo)
+	n := Dtof(d, fl.sr) / 2
+	if n < 1 {
+		n = 1
+	}
+	fl.tzl = newbufc(n, 0)
+}
+
+// SetFeedback sets amount of delayed signal fed back where fb belongs to (-1..1).
+func (fl *Flanger) SetFeedback(fb float64) { fl.fb = clamp(fb, -0.99, 0.99) }
+
+// SetThroughZero sets whether sweep passes through the original signal.
+func (fl *Flanger) SetThroughZero(b bool) { fl.tz = b }
+
+// SetMix sets amplitude of delayed and original signal.
+func (fl *Flanger) SetMix(wet, dry float64) { fl.wet, fl.dry = wet, dry }
+
+func (fl *Flanger) Inputs() []Sound { return []Sound{fl.in, fl.lfo} }
+
+func (fl *Flanger) Prepare(uint64) {
+	for i := range fl.out {
+		x := fl.in.Index(i)
+		y := fl.vt.read(fl.line, fl.line.w, i)
+		fl.line.write(x + fl.fb*y)
+
+		xd := fl.tzl.read()
+		fl.tzl.write(x)
+		if fl.off {
+			fl.out[i] = 0
+		} else if fl.tz {
+			// inverted wet cancels delayed dry as sweep crosses it
+			fl.out[i] = xd*fl.dry - y*fl.wet
+		} else {
+			fl.out[i] = x*fl.dry + y*fl.wet
+		}
+	}
+}
+
+// Vibrato modulates pitch of a signal by reading it from a delay line at a
+// periodically varying time.
+type Vibrato struct {
+	*mono
+	vt   vtime
+	lfo  *LFO
+	line *bufc
+
+	wet, dry float64
+}
+
+// NewVibrato returns Vibrato sweeping 2ms at 5Hz.
+func NewVibrato(in Sound) *Vibrato {
+	sr := in.SampleRate()
+	max := Dtof(MaxChorusDelay, sr)
+	vb := &Vibrato{
+		mono: newmono(in),
+		vt:   newvtime(0, max, sr),
+		lfo:  NewLFO(LFOSine, 5),
+		line: newbufc(max+2, 0),
+		wet:  1,
+	}
+	vb.lfo.SetDepth(0.5, 0.5)
+	vb.SetDepth(2 * time.Millisecond)
+	vb.vt.SetInterp(InterpCubic)
+	return vb
+}
+
+// SetRate sets modulation rate in hertz.
+func (vb *Vibrato) SetRate(hz float64) { vb.lfo.SetFreq(hz) }
+
+// SetSync sets modulation rate to one cycle per note division div at bpm.
+func (vb *Vibrato) SetSync(bpm BPM, div Division) { vb.lfo.SetSync(bpm, div) }
+
+// SetDepth sets greatest delay of modulation, limited to MaxChorusDelay.
+func (vb *Vibrato) SetDepth(d time.Duration) {
+	if d > MaxChorusDelay {
+		d = MaxChorusDelay
+	}
+	vb.vt.SetTime(d, vb.lfo)
+}
+
+// SetMix sets amplitude of modulated and original signal.
+func (vb *Vibrato) SetMix(wet, dry float64) { vb.wet, vb.dry = wet, dry }
+
+func (vb *Vibrato) Inputs() []Sound { return []Sound{vb.in, vb.lfo} }
+
+func (vb *Vibrato) Prepare(uint64) {
+	for i := range vb.out {
+		x := vb.in.Index(i)
+		y := vb.vt.read(vb.line, vb.line.w, i)
+		vb.line.write(x)
+		if vb.off {
+			vb.out[i] = 0
+		} else {
+			vb.out[i] = x*vb.dry + y*vb.wet
+		}
+	}
+}
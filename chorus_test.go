@@ -0,0 +1,104 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestChorus(t *testing.T) {
+	ch := NewChorus(4, newtone(440))
+	ch.SetMix(1, 0)
+	inps := GetInputs(ch)
+	var diff float64
+	for tc := 1; tc <= 20; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+		out := ch.Samples()
+		for i := 0; i < len(out); i += 2 {
+			if math.IsNaN(out[i]) || math.Abs(out[i]) > 2 {
+				t.Fatalf("chorus out of range %v", out[i])
+			}
+			diff += math.Abs(out[i] - out[i+1])
+		}
+	}
+	if diff == 0 {
+		t.Fatal("chorus voices not spread across channels")
+	}
+}
+
+func TestFlangerThroughZero(t *testing.T) {
+	// hold sweep at center where original and delayed signals align
+	for _, tz := range []bool{false, true} {
+		fl := NewFlanger(newtone(100))
+		fl.SetFeedback(0)
+		fl.SetThroughZero(tz)
+		fl.SetRate(0)
+		fl.lfo.SetDepth(0, 0.5)
+		a := amplitude(fl, 20, 20)
+		if tz && a > 0.01 {
+			t.Errorf("through-zero at center have amplitude %v, want 0", a)
+		} else if !tz && a < 1.9 {
+			t.Errorf("flanger at center have amplitude %v, want 2", a)
+		}
+	}
+}
+
+func TestVibrato(t *testing.T) {
+	vb := NewVibrato(newtone(440))
+	vb.SetSync(120, Quarter)
+	if hz := vb.lfo.Freq(); !equals(hz, 2) {
+		t.Fatalf("synced rate have %vHz, want 2Hz", hz)
+	}
+	if a := amplitude(vb, 4, 40); !equaleps(a, 1, 0.01) {
+		t.Fatalf("vibrato amplitude have %v, want 1", a)
+	}
+}
+
+func TestChorusStereo(t *testing.T) {
+	// input panned left remains left
+	sd := NewChorus(4, NewPan(-1, newtone(440)))
+	inps := GetInputs(sd)
+	var l float64
+	for tc := 1; tc <= 20; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+		out := sd.Samples()
+		for i := 0; i < len(out); i += 2 {
+			l += math.Abs(out[i])
+			if out[i+1] != 0 {
+				t.Fatalf("have right %v, want 0 [tc=%v i=%v]", out[i+1], tc, i/2)
+			}
+		}
+	}
+	if l == 0 {
+		t.Fatal("have silent left, want input")
+	}
+}
+
+func BenchmarkChorus(b *testing.B) {
+	ch := NewChorus(4, newunit())
+	inps := GetInputs(ch)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(n))
+		}
+	}
+}
+
+func BenchmarkFlanger(b *testing.B) {
+	fl := NewFlanger(newunit())
+	fl.SetDepth(5 * time.Millisecond)
+	inps := GetInputs(fl)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(n))
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package snd
+
+import (
+	"math"
+	"time"
+)
+
+// gainout is gain applied by a dynamics processor and is prepared by it.
+type gainout struct{ *mono }
+
+func (out *gainout) Prepare(uint64) {}
+
+// detector measures level of a signal by peak or root mean square.
+type detector struct {
+	rms bool
+	a   float64 // smoothing coefficient of mean square
+	ms  float64
+}
+
+// level returns level of x in decibels.
+func (d *detector) level(x float64) float64 {
+	if d.rms {
+		d.ms = x*x + d.a*(d.ms-x*x)
+		x = math.Sqrt(d.ms)
+	}
+	return 20 * math.Log10(math.Max(math.Abs(x), 1e-10))
+}
+
+// Compressor is a feed-forward compressor reducing level of a signal above
+// a threshold by a ratio.
+//
+// Level is detected from input or from a sidechain if set, such as a kick
+// drum ducking a pad. Gain reduction of left and right channels may be
+// linked so the stereo image does not shift.
+type Compressor struct {
+	*stereo
+	side Sound
+
+	thr, knee float64 // decibels
+	ratio     float64
+	link      float64
+	makeup    float64 // amplitude
+
+	det [2]detector
+	env [2]follower
+	sr  float64
+	red [2]float64 // current reduction in decibels
+
+	gr *gainout
+}
+
+// NewCompressor returns Compressor of in with threshold and ratio, 10ms
+// attack, 100ms release and peak detection.
+func NewCompressor(threshold Decibel, ratio float64, in Sound) *Compressor {
+	cmp := &Compressor{stereo: newstereo(in), link: 1, makeup: 1, sr: in.SampleRate()}
+	cmp.SetThreshold(threshold)
+	cmp.SetRatio(ratio)
+	cmp.SetTimes(10*time.Millisecond, 100*time.Millisecond)
+	cmp.SetRMS(false, 10*time.Millisecond)
+	cmp.gr = &gainout{newmono(cmp)}
+	return cmp
+}
+
+func (cmp *Compressor) SetThreshold(threshold Decibel) { cmp.thr = float64(threshold) }
+
+// SetRatio sets ratio of input to output level above threshold, at least 1.
+func (cmp *Compressor) SetRatio(ratio float64) { cmp.ratio = math.Max(ratio, 1) }
+
+// SetKnee sets width of the region around threshold where ratio is applied gradually.
+func (cmp *Compressor) SetKnee(knee Decibel) { cmp.knee = math.Max(float64(knee), 0) }
+
+// SetTimes sets time for gain reduction to rise after level exceeds threshold
+// and to fall after it recedes.
+func (cmp *Compressor) SetTimes(attack, release time.Duration) {
+	for c := range cmp.env {
+		cmp.env[c].set(attack, release, cmp.sr)
+	}
+}
+
+// SetMakeup sets gain applied after compression.
+func (cmp *Compressor) SetMakeup(makeup Decibel) { cmp.makeup = makeup.Amp() }
+
+// SetRMS sets detection to root mean square averaged over window, or peak if false.
+func (cmp *Compressor) SetRMS(rms bool, window time.Duration) {
+	for c := range cmp.det {
+		cmp.det[c].rms = rms
+		cmp.det[c].a = math.Exp(-1 / (window.Seconds() * cmp.sr))
+	}
+}
+
+// SetLink sets amount gain reduction of channels is shared where link belongs to [0..1].
+func (cmp *Compressor) SetLink(link float64) { cmp.link = clamp(link, 0, 1) }
+
+// SetSidechain sets sound level is detected from, or input if nil.
+func (cmp *Compressor) SetSidechain(side Sound) { cmp.side = side }
+
+// GainReduction returns gain applied before makeup as a Sound, suitable as a modulation source.
+func (cmp *Compressor) GainReduction() Sound { return cmp.gr }
+
+// Reduction returns current gain reduction as the greater of both channels.
+func (cmp *Compressor) Reduction() Decibel { return Decibel(math.Max(cmp.red[0], cmp.red[1])) }
+
+func (cmp *Compressor) Inputs() []Sound { return []Sound{cmp.in, cmp.side} }
+
+// curve returns gain reduction in decibels for level lvl.
+func (cmp *Compressor) curve(lvl float64) float64 {
+	over := lvl - cmp.thr
+	slope := 1 - 1/cmp.ratio
+	switch {
+	case 2*over <= -cmp.knee:
+		return 0
+	case 2*math.Abs(over) < cmp.knee:
+		x := over + cmp.knee/2
+		return slope * x * x / (2 * cmp.knee)
+	default:
+		return slope * over
+	}
+}
+
+// frame returns left and right samples of sd at frame i.
+func frame(sd Sound, i int) (l, r float64) {
+	xs := sd.Samples()
+	if sd.Channels() == 2 {
+		return xs[i*2], xs[i*2+1]
+	}
+	return xs[i], xs[i]
+}
+
+func (cmp *Compressor) Prepare(uint64) {
+	for i := range cmp.l.out {
+		l, r := frame(cmp.in, i)
+		dl, dr := l, r
+		if cmp.side != nil {
+			dl, dr = frame(cmp.side, i)
+		}
+
+		ll, lr := cmp.det[0].level(dl), cmp.det[1].level(dr)
+		if cmp.link > 0 {
+			mx := math.Max(ll, lr)
+			ll += cmp.link * (mx - ll)
+			lr += cmp.link * (mx - lr)
+		}
+		cmp.red[0] = cmp.env[0].next(cmp.curve(ll))
+		cmp.red[1] = cmp.env[1].next(cmp.curve(lr))
+
+		gl, gr := Decibel(-cmp.red[0]).Amp(), Decibel(-cmp.red[1]).Amp()
+		cmp.gr.out[i] = math.Min(gl, gr)
+		mk := cmp.makeup
+		if cmp.l.off {
+			cmp.l.out[i] = 0
+		} else {
+			cmp.l.out[i] = l * gl * mk
+		}
+		if cmp.r.off {
+			cmp.r.out[i] = 0
+		} else {
+			cmp.r.out[i] = r * gr * mk
+		}
+		cmp.out[i*2] = cmp.l.out[i]
+		cmp.out[i*2+1] = cmp.r.out[i]
+	}
+}
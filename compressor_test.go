@@ -0,0 +1,67 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCompressorCurve(t *testing.T) {
+	cmp := NewCompressor(-20, 4, newunit())
+	cmp.SetKnee(6)
+	for _, td := range []struct{ lvl, want float64 }{
+		{-40, 0},
+		{-23, 0},
+		{-20, 0.75 * 9 / 12},
+		{-17, 0.75 * 3},
+		{0, 15},
+	} {
+		if have := cmp.curve(td.lvl); !equals(have, td.want) {
+			t.Errorf("level %vdB have reduction %v, want %v", td.lvl, have, td.want)
+		}
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	// sine of unit amplitude has a root mean square of -3dB
+	cmp := NewCompressor(-23, 4, newtone(440))
+	cmp.SetRMS(true, 10*time.Millisecond)
+	cmp.SetMakeup(3)
+	a := amplitude(cmp, 40, 20)
+	if want := Decibel(-15 + 3).Amp(); !equaleps(a, want, 0.02) {
+		t.Fatalf("have amplitude %v, want %v", a, want)
+	}
+	if g := cmp.GainReduction().Samples()[0]; !equaleps(g, Decibel(-15).Amp(), 0.02) {
+		t.Fatalf("have gain reduction %v, want %v", g, Decibel(-15).Amp())
+	}
+	if db := cmp.Reduction(); math.Abs(float64(db)-15) > 0.5 {
+		t.Fatalf("have reduction %v, want 15dB", db)
+	}
+}
+
+func TestCompressorSidechain(t *testing.T) {
+	for _, td := range []struct {
+		side Sound
+		want float64
+	}{
+		{NewGain(0.01, newzeros()), 1}, // -40dB
+		{newzeros(), Decibel(-15).Amp()},
+	} {
+		cmp := NewCompressor(-20, 4, newtone(440))
+		cmp.SetSidechain(td.side)
+		if a := amplitude(cmp, 40, 20); !equaleps(a, td.want, 0.01) {
+			t.Errorf("have amplitude %v, want %v", a, td.want)
+		}
+	}
+}
+
+func BenchmarkCompressor(b *testing.B) {
+	cmp := NewCompressor(-20, 4, newunit())
+	cmp.SetKnee(6)
+	cmp.SetRMS(true, 10*time.Millisecond)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		cmp.Prepare(uint64(n))
+	}
+}
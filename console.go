@@ -0,0 +1,314 @@
+package snd
+
+import "math"
+
+// Strip is a channel of a Console applying gain, pan, mute, solo and phase
+// invert to a mono or stereo input, and sending it to buses.
+//
+// Changes are smoothed to avoid clicks. Pan of a stereo input balances its
+// channels, attenuating the opposite side.
+type Strip struct {
+	in  Sound
+	con *Console
+
+	gain, pan param
+	// target of on follows mute and solo, pol follows invert
+	on, pol param
+
+	mute, solo, invert bool
+
+	sends []*send
+	// frames before and after gain and pan, interleaved
+	pre, post []float64
+}
+
+func newstrip(in Sound, con *Console) *Strip {
+	sr := con.SampleRate()
+	return &Strip{
+		in:   in,
+		con:  con,
+		gain: newparam(1, nil, sr),
+		pan:  newparam(0, nil, sr),
+		on:   newparam(1, nil, sr),
+		pol:  newparam(1, nil, sr),
+		pre:  make([]float64, DefaultBufferLen*2),
+		post: make([]float64, DefaultBufferLen*2),
+	}
+}
+
+func (st *Strip) Input() Sound { return st.in }
+
+// SetGain sets gain of strip.
+func (st *Strip) SetGain(db Decibel) { st.gain.set(db.Amp(), nil) }
+
+func (st *Strip) Gain() Decibel { return Decibel(20 * math.Log10(st.gain.x)) }
+
+// SetPan sets pan of strip where xf belongs to [-1..1].
+func (st *Strip) SetPan(xf float64) { st.pan.set(clamp(xf, -1, 1), nil) }
+
+func (st *Strip) Pan() float64 { return st.pan.x }
+
+func (st *Strip) SetMute(mute bool) { st.mute = mute }
+func (st *Strip) Muted() bool       { return st.mute }
+
+// SetSolo sets whether strip is soloed; while any strip of a Console is
+// soloed, only soloed strips are heard.
+func (st *Strip) SetSolo(solo bool) { st.solo = solo }
+func (st *Strip) Soloed() bool      { return st.solo }
+
+// SetInvert sets whether polarity of input is inverted.
+func (st *Strip) SetInvert(invert bool) {
+	st.invert = invert
+	if invert {
+		st.pol.set(-1, nil)
+	} else {
+		st.pol.set(1, nil)
+	}
+}
+
+func (st *Strip) Inverted() bool { return st.invert }
+
+// send is level of a strip sent to a bus.
+type send struct {
+	bus   *Bus
+	level param
+	pre   bool
+}
+
+// Send sets level of strip sent to bus, before gain and pan of strip if pre,
+// otherwise after. Sends follow mute, solo and invert of strip either way.
+// Sends of returns and sends to a bus of another console are ignored.
+func (st *Strip) Send(bus *Bus, level Decibel, pre bool) {
+	if bus.con != st.con {
+		return
+	}
+	for _, sn := range st.sends {
+		if sn.bus == bus {
+			sn.level.set(level.Amp(), nil)
+			sn.pre = pre
+			return
+		}
+	}
+	st.sends = append(st.sends, &send{bus: bus, level: newparam(level.Amp(), nil, bus.SampleRate()), pre: pre})
+}
+
+// RemoveSend removes send of strip to bus.
+func (st *Strip) RemoveSend(bus *Bus) {
+	for i, sn := range st.sends {
+		if sn.bus == bus {
+			st.sends = append(st.sends[:i], st.sends[i+1:]...)
+			return
+		}
+	}
+}
+
+// frame returns left and right samples of strip at frame i before and
+// after gain and pan.
+func (st *Strip) frame(i int) (prel, prer, l, r float64) {
+	on := st.on.next(i) * st.pol.next(i)
+	g := st.gain.next(i)
+	xf := st.pan.next(i)
+	if st.in.Channels() == 2 {
+		l, r = frame(st.in, i)
+		l, r = l*on, r*on
+		return l, r, l * g * math.Min(1-xf, 1), r * g * math.Min(1+xf, 1)
+	}
+	x := st.in.Index(i) * on
+	return x * onesqrt2, x * onesqrt2, x * g * getpanfac(xf), x * g * getpanfac(-xf)
+}
+
+// Bus is a named stereo sum of strips sent to it, such as the input of a
+// reverb shared by many voices. Its output is typically returned to the
+// master of its Console by Return.
+type Bus struct {
+	*stereo
+	name string
+	con  *Console
+}
+
+func (bus *Bus) Name() string { return bus.name }
+
+func (bus *Bus) Inputs() []Sound { return []Sound{bus.con.chans} }
+
+func (bus *Bus) Prepare(uint64) {
+	for i := range bus.out {
+		bus.out[i] = 0
+	}
+	for _, st := range bus.con.strips {
+		for _, sn := range st.sends {
+			if sn.bus != bus {
+				continue
+			}
+			buf := st.post
+			if sn.pre {
+				buf = st.pre
+			}
+			for i := range bus.l.out {
+				g := sn.level.next(i)
+				bus.out[i*2] += buf[i*2] * g
+				bus.out[i*2+1] += buf[i*2+1] * g
+			}
+		}
+	}
+	for i := range bus.l.out {
+		if bus.l.off {
+			bus.out[i*2] = 0
+		}
+		if bus.r.off {
+			bus.out[i*2+1] = 0
+		}
+		bus.l.out[i] = bus.out[i*2]
+		bus.r.out[i] = bus.out[i*2+1]
+	}
+}
+
+// channels prepares strips of a console that are not returns, for both
+// buses and master to sum.
+type channels struct {
+	*mono
+	con *Console
+}
+
+func (ch *channels) Inputs() []Sound {
+	ins := make([]Sound, len(ch.con.strips))
+	for i, st := range ch.con.strips {
+		ins[i] = st.in
+	}
+	return ins
+}
+
+func (ch *channels) Prepare(uint64) {
+	solo := false
+	for _, st := range ch.con.strips {
+		solo = solo || st.solo
+	}
+	for _, st := range ch.con.strips {
+		if st.mute || solo && !st.solo {
+			st.on.set(0, nil)
+		} else {
+			st.on.set(1, nil)
+		}
+		for i := range ch.out {
+			st.pre[i*2], st.pre[i*2+1], st.post[i*2], st.post[i*2+1] = st.frame(i)
+		}
+	}
+}
+
+// Console is a stereo mixer of strips.
+//
+// Unlike Mixer, each input is given its own gain, pan, mute, solo and phase
+// invert through the Strip returned on adding it. Strips may be sent to named
+// buses whose processed output is then returned to the master through strips
+// of its own. Returns are not silenced by solo of other strips.
+type Console struct {
+	*stereo
+	strips  []*Strip
+	returns []*Strip
+	buses   []*Bus
+	chans   *channels
+}
+
+// NewConsole returns Console with a strip added for each of ins.
+func NewConsole(ins ...Sound) *Console {
+	con := &Console{stereo: newstereo(nil)}
+	con.chans = &channels{newmono(nil), con}
+	for _, in := range ins {
+		con.Add(in)
+	}
+	return con
+}
+
+// Add returns a new strip of in appended to console.
+func (con *Console) Add(in Sound) *Strip {
+	st := newstrip(in, con)
+	con.strips = append(con.strips, st)
+	return st
+}
+
+// Bus returns bus of console with name, adding it if none exists.
+func (con *Console) Bus(name string) *Bus {
+	for _, bus := range con.buses {
+		if bus.name == name {
+			return bus
+		}
+	}
+	bus := &Bus{stereo: newstereo(nil), name: name, con: con}
+	con.buses = append(con.buses, bus)
+	return bus
+}
+
+// Buses returns buses of console in order added.
+func (con *Console) Buses() []*Bus { return con.buses }
+
+// Return returns a new strip of in summed into master, such as an effect
+// processing a bus. Input of a return must not depend on output of console.
+func (con *Console) Return(in Sound) *Strip {
+	st := newstrip(in, con)
+	con.returns = append(con.returns, st)
+	return st
+}
+
+// Remove removes strip or return from console.
+func (con *Console) Remove(st *Strip) {
+	con.strips = removestrip(con.strips, st)
+	con.returns = removestrip(con.returns, st)
+}
+
+func removestrip(sts []*Strip, st *Strip) []*Strip {
+	for i, x := range sts {
+		if x == st {
+			return append(sts[:i], sts[i+1:]...)
+		}
+	}
+	return sts
+}
+
+// Strips returns strips of console in order added, excluding returns.
+func (con *Console) Strips() []*Strip { return con.strips }
+
+// Returns returns strips of console summing returns in order added.
+func (con *Console) Returns() []*Strip { return con.returns }
+
+func (con *Console) Inputs() []Sound {
+	// strips last as buses returned also depend on them
+	ins := make([]Sound, 0, len(con.returns)+1)
+	for _, st := range con.returns {
+		ins = append(ins, st.in)
+	}
+	return append(ins, con.chans)
+}
+
+func (con *Console) Prepare(uint64) {
+	for _, st := range con.returns {
+		if st.mute {
+			st.on.set(0, nil)
+		} else {
+			st.on.set(1, nil)
+		}
+	}
+
+	for i := range con.l.out {
+		var l, r float64
+		for _, st := range con.strips {
+			l += st.post[i*2]
+			r += st.post[i*2+1]
+		}
+		for _, st := range con.returns {
+			_, _, sl, sr := st.frame(i)
+			l += sl
+			r += sr
+		}
+		if con.l.off {
+			con.l.out[i] = 0
+		} else {
+			con.l.out[i] = l
+		}
+		if con.r.off {
+			con.r.out[i] = 0
+		} else {
+			con.r.out[i] = r
+		}
+		con.out[i*2] = con.l.out[i]
+		con.out[i*2+1] = con.r.out[i]
+	}
+}
@@ -0,0 +1,168 @@
+package snd
+
+import (
+	"math"
+	"testing"
+)
+
+// settle prepares con and its inputs until parameter changes are smoothed,
+// returning last frame.
+func settle(con *Console) (l, r float64) {
+	inps := GetInputs(con)
+	for tc := 1; tc <= 20; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+	}
+	n := len(con.l.out) - 1
+	return con.l.out[n], con.r.out[n]
+}
+
+func TestConsolePan(t *testing.T) {
+	for _, td := range []struct {
+		in           Sound
+		pan          float64
+		wantl, wantr float64
+	}{
+		{newunit(), 0, onesqrt2 * DefaultAmpFac, onesqrt2 * DefaultAmpFac},
+		{newunit(), 1, 0, DefaultAmpFac},
+		{newunit(), -1, DefaultAmpFac, 0},
+		{NewPan(0, newunit()), 0, onesqrt2 * DefaultAmpFac, onesqrt2 * DefaultAmpFac},
+		{NewPan(0, newunit()), 0.5, onesqrt2 * DefaultAmpFac / 2, onesqrt2 * DefaultAmpFac},
+		{NewPan(0, newunit()), -1, onesqrt2 * DefaultAmpFac, 0},
+	} {
+		con := NewConsole()
+		con.Add(td.in).SetPan(td.pan)
+		if l, r := settle(con); !equaleps(l, td.wantl, 1e-6) || !equaleps(r, td.wantr, 1e-6) {
+			t.Errorf("channels %v pan %v have (%v, %v), want (%v, %v)", td.in.Channels(), td.pan, l, r, td.wantl, td.wantr)
+		}
+	}
+}
+
+func TestConsoleStrip(t *testing.T) {
+	con := NewConsole(newunit(), newunit(), newunit())
+	sts := con.Strips()
+	for _, st := range sts {
+		st.SetPan(-1)
+	}
+	check := func(want float64) {
+		t.Helper()
+		if l, r := settle(con); !equaleps(l, want, 1e-6) || r != 0 {
+			t.Errorf("have (%v, %v), want (%v, 0)", l, r, want)
+		}
+	}
+	check(3 * DefaultAmpFac)
+
+	sts[0].SetGain(-6)
+	check((2 + Decibel(-6).Amp()) * DefaultAmpFac)
+
+	sts[1].SetMute(true)
+	check((1 + Decibel(-6).Amp()) * DefaultAmpFac)
+
+	sts[2].SetInvert(true)
+	check((Decibel(-6).Amp() - 1) * DefaultAmpFac)
+
+	// muted strip is silent even if soloed
+	sts[1].SetSolo(true)
+	check(0)
+	sts[1].SetMute(false)
+	check(DefaultAmpFac)
+
+	con.Remove(sts[1])
+	if n := len(con.Strips()); n != 2 {
+		t.Fatalf("have %v strips, want 2", n)
+	}
+	check((Decibel(-6).Amp() - 1) * DefaultAmpFac)
+}
+
+func TestConsoleSmoothing(t *testing.T) {
+	con := NewConsole(newunit())
+	st := con.Strips()[0]
+	settle(con)
+	st.SetMute(true)
+	con.chans.Prepare(1)
+	con.Prepare(1)
+	// change is spread over frames rather than applied at once
+	if x := con.l.out[0]; x == 0 || x >= onesqrt2*DefaultAmpFac {
+		t.Fatalf("have %v on first frame after mute, want between 0 and %v", x, onesqrt2*DefaultAmpFac)
+	}
+}
+
+func TestConsoleBus(t *testing.T) {
+	con := NewConsole(newunit(), newunit())
+	sts := con.Strips()
+	sts[0].SetGain(-6)
+	sts[0].SetPan(-1)
+	sts[1].SetGain(Decibel(math.Inf(-1)))
+
+	fx := con.Bus("fx")
+	if con.Bus("fx") != fx {
+		t.Fatal("bus of same name not reused")
+	}
+	sts[0].Send(fx, 0, false)
+	sts[1].Send(fx, -6, true)
+	con.Return(fx)
+
+	// bus is discovered and prepared after strips
+	var order []Sound
+	for _, inp := range GetInputs(con) {
+		if inp.sd == fx || inp.sd == con.chans {
+			order = append(order, inp.sd)
+		}
+	}
+	if len(order) != 2 || order[0] != con.chans {
+		t.Fatalf("have order %v, want strips then bus", order)
+	}
+
+	a6 := Decibel(-6).Amp()
+	busl := DefaultAmpFac * (a6 + a6*onesqrt2)
+	busr := DefaultAmpFac * a6 * onesqrt2
+	l, r := settle(con)
+	if have := fx.Samples()[len(fx.Samples())-2:]; !equaleps(have[0], busl, 1e-6) || !equaleps(have[1], busr, 1e-6) {
+		t.Errorf("have bus %v, want [%v %v]", have, busl, busr)
+	}
+	if want := DefaultAmpFac*a6 + busl; !equaleps(l, want, 1e-6) || !equaleps(r, busr, 1e-6) {
+		t.Errorf("have (%v, %v), want (%v, %v)", l, r, want, busr)
+	}
+
+	// pre-fader sends follow mute
+	sts[1].SetMute(true)
+	settle(con)
+	if have := fx.Samples()[len(fx.Samples())-1]; !equaleps(have, 0, 1e-6) {
+		t.Errorf("have bus %v with send muted, want 0", have)
+	}
+	sts[0].RemoveSend(fx)
+	settle(con)
+	if have := fx.Samples()[len(fx.Samples())-2]; !equaleps(have, 0, 1e-6) {
+		t.Errorf("have bus %v with send removed, want 0", have)
+	}
+}
+
+func TestConsoleSendOther(t *testing.T) {
+	// sends to a bus of another console are ignored
+	con, other := NewConsole(newunit()), NewConsole()
+	st := con.Strips()[0]
+	st.Send(other.Bus("fx"), 0, false)
+	if n := len(st.sends); n != 0 {
+		t.Fatalf("have %v sends, want 0", n)
+	}
+	st.Send(con.Bus("fx"), 0, false)
+	if n := len(st.sends); n != 1 {
+		t.Fatalf("have %v sends, want 1", n)
+	}
+}
+
+func BenchmarkConsole(b *testing.B) {
+	con := NewConsole()
+	for i := 0; i < 4; i++ {
+		con.Add(newunit()).SetPan(0.5)
+	}
+	inps := GetInputs(con)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(n + 1))
+		}
+	}
+}
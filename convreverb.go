@@ -0,0 +1,179 @@
+package snd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"dasa.cc/signal"
+)
+
+// ConvReverb is a convolution reverb of a mono or stereo impulse response.
+//
+// Convolution is uniformly partitioned by buffer length so no latency is
+// introduced. Input may be mono or stereo and output is always stereo; a mono
+// impulse response is applied to both channels.
+//
+// Changing pre-delay or length recalculates the impulse response partitions
+// and allocates; do so ahead of time rather than during playback if possible.
+type ConvReverb struct {
+	*stereo
+	irs []signal.Discrete
+
+	predelay time.Duration
+	length   time.Duration
+	wet, dry float64
+
+	cvl, cvr *convolver
+	xl, xr   []float64 // deinterleaved input
+}
+
+// NewConvReverb returns reverb of impulse response ir given as one or two
+// channels at the sample rate of in. Output is fully wet. An impulse response
+// without channels is silent.
+func NewConvReverb(ir []signal.Discrete, in Sound) *ConvReverb {
+	if len(ir) > 2 {
+		ir = ir[:2]
+	}
+	if len(ir) == 0 {
+		ir = []signal.Discrete{nil}
+	}
+	cr := &ConvReverb{stereo: newstereo(in), irs: ir, wet: 1}
+	cr.xl = make([]float64, len(cr.l.out))
+	cr.xr = make([]float64, len(cr.r.out))
+	cr.update()
+	return cr
+}
+
+// LoadConvReverb returns reverb of a WAVE encoded impulse response read from
+// r, resampled to the sample rate of in if required.
+func LoadConvReverb(r io.Reader, in Sound) (*ConvReverb, error) {
+	ir, sr, err := DecodeWAV(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(ir[0]) == 0 {
+		return nil, fmt.Errorf("snd: impulse response is empty")
+	}
+	if sr != in.SampleRate() {
+		for i, sig := range ir {
+			ir[i] = resample(sig, sr, in.SampleRate())
+		}
+	}
+	return NewConvReverb(ir, in), nil
+}
+
+// resample returns sig at sample rate from converted to sample rate to by linear
+// interpolation. If to is lower, sig is first filtered of frequencies above its
+// nyquist frequency that would otherwise alias.
+func resample(sig signal.Discrete, from, to float64) signal.Discrete {
+	if to < from {
+		sig = lowpass(sig, LowPassFIR(int(32*from/to), 0.45*to, from, KaiserWindow(8)))
+	}
+	n := int(float64(len(sig)) * to / from)
+	out := make(signal.Discrete, n)
+	for i := range out {
+		t := float64(i) * from / to
+		j := int(t)
+		if j+1 >= len(sig) {
+			out[i] = sig[len(sig)-1]
+			continue
+		}
+		out[i] = sig[j] + (t-float64(j))*(sig[j+1]-sig[j])
+	}
+	return out
+}
+
+// lowpass returns sig convolved with odd length h, aligned so sig is not delayed.
+func lowpass(sig, h signal.Discrete) signal.Discrete {
+	c := len(h) / 2
+	out := make(signal.Discrete, len(sig))
+	for i := range out {
+		var y float64
+		for k, x := range h {
+			if j := i + c - k; j >= 0 && j < len(sig) {
+				y += x * sig[j]
+			}
+		}
+		out[i] = y
+	}
+	return out
+}
+
+// SetMix sets amplitude of reverberated and original signal.
+func (cr *ConvReverb) SetMix(wet, dry float64) {
+	cr.wet, cr.dry = wet, dry
+}
+
+// SetPreDelay delays onset of reverberation by d.
+func (cr *ConvReverb) SetPreDelay(d time.Duration) {
+	cr.predelay = d
+	cr.update()
+}
+
+// SetLength trims impulse response to d with a short fade out. A d of zero
+// uses the full impulse response.
+func (cr *ConvReverb) SetLength(d time.Duration) {
+	cr.length = d
+	cr.update()
+}
+
+// trim returns ir with pre-delay and length applied.
+func (cr *ConvReverb) trim(ir signal.Discrete) []float64 {
+	sr := cr.SampleRate()
+	n := len(ir)
+	if cr.length > 0 {
+		if f := Dtof(cr.length, sr); f < n {
+			n = f
+		}
+	}
+	pre := Dtof(cr.predelay, sr)
+	h := make([]float64, pre+n)
+	copy(h[pre:], ir[:n])
+	if n < len(ir) {
+		fade := Dtof(10*time.Millisecond, sr)
+		if fade > n {
+			fade = n
+		}
+		for i := 0; i < fade; i++ {
+			h[pre+n-1-i] *= float64(i) / float64(fade)
+		}
+	}
+	return h
+}
+
+func (cr *ConvReverb) update() {
+	b := len(cr.l.out)
+	cr.cvl = newconvolver(cr.trim(cr.irs[0]), b)
+	cr.cvr = newconvolver(cr.trim(cr.irs[len(cr.irs)-1]), b)
+}
+
+func (cr *ConvReverb) Prepare(uint64) {
+	in := cr.in.Samples()
+	if cr.in.Channels() == 2 {
+		for i := range cr.xl {
+			cr.xl[i], cr.xr[i] = in[i*2], in[i*2+1]
+		}
+	} else {
+		copy(cr.xl, in)
+		copy(cr.xr, in)
+	}
+
+	cr.cvl.process(cr.xl, cr.l.out)
+	cr.cvr.process(cr.xr, cr.r.out)
+
+	for i := range cr.l.out {
+		if cr.l.off {
+			cr.l.out[i] = 0
+		} else {
+			cr.l.out[i] = cr.wet*cr.l.out[i] + cr.dry*cr.xl[i]
+		}
+		if cr.r.off {
+			cr.r.out[i] = 0
+		} else {
+			cr.r.out[i] = cr.wet*cr.r.out[i] + cr.dry*cr.xr[i]
+		}
+		cr.out[i*2] = cr.l.out[i]
+		cr.out[i*2+1] = cr.r.out[i]
+	}
+}
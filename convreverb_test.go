@@ -0,0 +1,111 @@
+package snd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"dasa.cc/signal"
+)
+
+func TestConvReverb(t *testing.T) {
+	cr := NewConvReverb([]signal.Discrete{{1}, {0.5}}, newunit())
+	cr.Prepare(1)
+	for i, x := range cr.Samples() {
+		want := DefaultAmpFac
+		if i%2 == 1 {
+			want /= 2
+		}
+		if !equals(x, want) {
+			t.Fatalf("sample %v have %v, want %v", i, x, want)
+		}
+	}
+
+	cr = NewConvReverb([]signal.Discrete{{1}}, newunit())
+	cr.SetPreDelay(Ftod(100, cr.SampleRate()) + time.Microsecond)
+	cr.SetMix(1, 1)
+	cr.Prepare(1)
+	if x := cr.Samples()[2*99]; !equals(x, DefaultAmpFac) {
+		t.Fatalf("pre-delayed sample have %v, want dry only", x)
+	}
+	if x := cr.Samples()[2*100]; !equals(x, 2*DefaultAmpFac) {
+		t.Fatalf("pre-delayed sample have %v, want wet and dry", x)
+	}
+}
+
+func TestConvReverbLength(t *testing.T) {
+	ir := make(signal.Discrete, 4800)
+	for i := range ir {
+		ir[i] = 1
+	}
+	cr := NewConvReverb([]signal.Discrete{ir}, newunit())
+	cr.SetLength(50 * time.Millisecond)
+	if n := len(cr.cvl.h) * len(cr.l.out); n < 2400 || n >= 2400+len(cr.l.out) {
+		t.Fatalf("trimmed impulse response has %v frames of partitions", n)
+	}
+}
+
+func TestLoadConvReverb(t *testing.T) {
+	ir := make([]float64, 441)
+	ir[0] = 1
+	b := mkwav(3, 1, 32, 44100, ir, func(b []byte, x float64) {
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(x)))
+	})
+	cr, err := LoadConvReverb(bytes.NewReader(b), newunit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cr.irs[0]); n != 480 {
+		t.Fatalf("resampled impulse response has length %v, want 480", n)
+	}
+}
+
+func TestResample(t *testing.T) {
+	tone := func(hz, sr float64) signal.Discrete {
+		sig := make(signal.Discrete, 9600)
+		for i := range sig {
+			sig[i] = math.Sin(twopi * hz * float64(i) / sr)
+		}
+		return sig
+	}
+	// passband is unchanged and content above nyquist does not alias
+	if a := goertzel(resample(tone(1000, 96000), 96000, 48000), 1000, 48000); !equaleps(a, 1, 0.01) {
+		t.Fatalf("have amplitude %v at 1kHz, want 1", a)
+	}
+	if a := goertzel(resample(tone(30000, 96000), 96000, 48000), 18000, 48000); a > 0.001 {
+		t.Fatalf("have amplitude %v aliased to 18kHz, want 0", a)
+	}
+}
+
+func TestConvReverbEmpty(t *testing.T) {
+	for _, ir := range [][]signal.Discrete{nil, {nil}, {{}, {}}} {
+		cr := NewConvReverb(ir, newunit())
+		cr.SetPreDelay(10 * time.Millisecond)
+		cr.Prepare(1)
+		for _, x := range cr.Samples() {
+			if x != 0 {
+				t.Fatalf("%v channels have %v, want 0", len(ir), x)
+			}
+		}
+	}
+
+	b := mkwav(3, 1, 32, 44100, nil, nil)
+	if _, err := LoadConvReverb(bytes.NewReader(b), newunit()); err == nil {
+		t.Fatal("expected error loading empty impulse response")
+	}
+}
+
+func BenchmarkConvReverb(b *testing.B) {
+	ir := make(signal.Discrete, Dtof(2*time.Second, DefaultSampleRate))
+	for i := range ir {
+		ir[i] = math.Exp(-float64(i) / 20000)
+	}
+	cr := NewConvReverb([]signal.Discrete{ir}, newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		cr.Prepare(uint64(n))
+	}
+}
@@ -1,9 +1,6 @@
 package snd
 
-import (
-	"log"
-	"time"
-)
+import "time"
 
 // bufc implements a circular buffer.
 type bufc struct {
@@ -23,15 +20,23 @@ func (b *bufc) read() (x float64) {
 	return
 }
 
-// readat returns x at r and next read position.
-func (b *bufc) readat(r int) (x float64, n int) {
-	n = r
-	x = b.xs[n]
-	n++
-	if n == len(b.xs) {
-		n = 0
+// frac returns x written d frames ago, where d belongs to [1..len(xs)], by linear interpolation.
+func (b *bufc) frac(d float64) float64 {
+	n := len(b.xs)
+	p := float64(b.w) - d
+	for p < 0 {
+		p += float64(n)
 	}
-	return
+	i := int(p)
+	t := p - float64(i)
+	if i >= n {
+		i -= n
+	}
+	j := i + 1
+	if j == n {
+		j = 0
+	}
+	return b.xs[i] + t*(b.xs[j]-b.xs[i])
 }
 
 func (b *bufc) write(x float64) (end bool) {
@@ -54,23 +59,108 @@ func Ftod(f int, sr float64) (d time.Duration) {
 	return time.Duration(float64(f) / sr * float64(time.Second))
 }
 
+// Interpolation is the method of reading a delay line between frames.
+type Interpolation int
+
+const (
+	InterpLinear  Interpolation = iota
+	InterpAllPass               // flat magnitude response, best when modulated slowly
+	InterpCubic                 // four point hermite
+)
+
+// ago returns x written k frames before write position w.
+func (b *bufc) ago(w, k int) float64 {
+	i := w - k
+	for i < 0 {
+		i += len(b.xs)
+	}
+	return b.xs[i]
+}
+
+// vtime is a delay time in frames that may be fractional, smoothed and modulated.
+type vtime struct {
+	sr     float64
+	time   param
+	max    float64
+	interp Interpolation
+	ap     float64 // previous output of allpass interpolation
+}
+
+func newvtime(d time.Duration, max int, sr float64) vtime {
+	return vtime{sr: sr, time: newparam(float64(Dtof(d, sr)), nil, sr), max: float64(max)}
+}
+
+// newlinetime returns vtime of a delay line of n frames, delaying by a frame
+// less than n as a line read before written.
+func newlinetime(n int, sr float64) vtime {
+	return vtime{sr: sr, time: newparam(float64(n-1), nil, sr), max: float64(n)}
+}
+
+// SetTime sets delay to d, multiplied by mod if not nil, and limited to
+// length of delay line. Changes are smoothed over ParamSmoothing.
+func (vt *vtime) SetTime(d time.Duration, mod Sound) {
+	vt.time.set(d.Seconds()*vt.sr, mod)
+}
+
+// SetInterp sets method of reading delay line between frames.
+func (vt *vtime) SetInterp(interp Interpolation) { vt.interp = interp }
+
+// read returns x of b at frame i delayed relative to write position w.
+func (vt *vtime) read(b *bufc, w, i int) float64 {
+	d := clamp(vt.time.next(i), 1, vt.max)
+	n := int(d)
+	t := d - float64(n)
+	if t == 0 && vt.interp != InterpAllPass {
+		return b.ago(w, n)
+	}
+	switch vt.interp {
+	case InterpAllPass:
+		// keep fraction within [0.5..1.5) so coefficient stays away from unity
+		if t < 0.5 && n > 1 {
+			n, t = n-1, t+1
+		}
+		eta := (1 - t) / (1 + t)
+		vt.ap = eta*b.ago(w, n) + b.ago(w, n+1) - eta*vt.ap
+		return vt.ap
+	case InterpCubic:
+		if n >= 2 && n+2 <= len(b.xs) {
+			x0, x1, x2, x3 := b.ago(w, n-1), b.ago(w, n), b.ago(w, n+1), b.ago(w, n+2)
+			c1 := (x2 - x0) / 2
+			c2 := x0 - 2.5*x1 + 2*x2 - x3/2
+			c3 := (x3-x0)/2 + 1.5*(x1-x2)
+			return ((c3*t+c2)*t+c1)*t + x1
+		}
+	}
+	x0, x1 := b.ago(w, n), b.ago(w, n+1)
+	return x0 + t*(x1-x0)
+}
+
 // Delay represents a signal delayed by a given duration.
+//
+// Delay time may be changed or modulated up to the duration given at
+// construction. Until changed, input is delayed by a frame less than the
+// duration in frames.
 type Delay struct {
 	*mono
+	vtime
 	line *bufc
 }
 
 // NewDelay returns Delay with sample buffer of a length approximated by d.
 func NewDelay(d time.Duration, in Sound) *Delay {
-	return &Delay{newmono(in), newbufc(Dtof(d, in.SampleRate()), 1)}
+	sr := in.SampleRate()
+	n := Dtof(d, sr)
+	return &Delay{newmono(in), newlinetime(n, sr), newbufc(n+2, 0)}
 }
 
+func (dly *Delay) Inputs() []Sound { return []Sound{dly.in, dly.time.mod} }
+
 func (dly *Delay) Prepare(uint64) {
 	for i := range dly.out {
 		if dly.off {
 			dly.out[i] = 0
 		} else {
-			dly.out[i] = dly.line.read()
+			dly.out[i] = dly.read(dly.line, dly.line.w, i)
 			dly.line.write(dly.in.Index(i))
 		}
 	}
@@ -78,144 +168,93 @@ func (dly *Delay) Prepare(uint64) {
 
 // Tap is a tapped delay line, essentially a shorter delay within a larger one.
 //
-// TODO consider some type of method on Delay instead of a separate type.
-// For example, Tap intentionally does not expose dly via Inputs() so why is it
-// its own type? Conversely, that'd make Delay a mixer of sorts.
+// Tap remains in phase with Delay while toggled off itself, but not while
+// Delay is toggled off; see TapDelay for taps configured on the delay itself.
 type Tap struct {
 	*mono
+	vtime
 	dly *Delay
-	r   int
+	w   int
 }
 
 func NewTap(d time.Duration, in *Delay) *Tap {
-	f := Dtof(d, in.SampleRate())
-	n := len(in.line.xs)
-	if f >= n {
-		f = n - 1
-	}
-	// TODO this would fall out of sync if toggled off
-	// separate from Delay suggesting a more intrinsic design
-	// is required here.
-	//
-	// get ahead of the delay's write position
-	r := in.line.w + (n - f)
-	if r >= n {
-		r -= n
-	}
-	return &Tap{newmono(nil), in, r}
+	sr := in.SampleRate()
+	// track the delay's write position
+	return &Tap{newmono(nil), newvtime(d, int(in.max), sr), in, in.line.w}
 }
 
+func (tap *Tap) Inputs() []Sound { return []Sound{tap.time.mod} }
+
 func (tap *Tap) Prepare(uint64) {
 	for i := range tap.out {
 		if tap.off {
 			tap.out[i] = 0
 		} else {
-			tap.out[i], tap.r = tap.dly.line.readat(tap.r)
+			tap.out[i] = tap.read(tap.dly.line, tap.w, i)
+		}
+		if tap.w++; tap.w == len(tap.dly.line.xs) {
+			tap.w = 0
 		}
 	}
 }
 
 // Comb adds a delayed version of a signal onto itself.
+//
+// As with Delay, the signal is delayed by a frame less than the duration in
+// frames until changed.
 type Comb struct {
 	*mono
+	vtime
 	line *bufc
 	gain float64
+
+	// lowpass coefficient and state of feedback
+	damp, lp float64
 }
 
 func NewComb(gain float64, d time.Duration, in Sound) *Comb {
-	return &Comb{newmono(in), newbufc(Dtof(d, in.SampleRate()), 1), gain}
+	sr := in.SampleRate()
+	n := Dtof(d, sr)
+	return &Comb{mono: newmono(in), vtime: newlinetime(n, sr), line: newbufc(n+2, 0), gain: gain}
 }
 
+// SetDamp sets amount high frequencies are absorbed on each repetition where damp belongs to [0..1).
+func (cmb *Comb) SetDamp(damp float64) { cmb.damp = damp }
+
+func (cmb *Comb) Inputs() []Sound { return []Sound{cmb.in, cmb.time.mod} }
+
 func (cmb *Comb) Prepare(uint64) {
 	for i := range cmb.out {
 		if cmb.off {
 			cmb.out[i] = 0
 		} else {
-			cmb.out[i] = cmb.line.read()
-			cmb.line.write(cmb.in.Index(i) + cmb.out[i]*cmb.gain)
+			cmb.out[i] = cmb.read(cmb.line, cmb.line.w, i)
+			cmb.lp = cmb.out[i]*(1-cmb.damp) + cmb.lp*cmb.damp
+			cmb.line.write(cmb.in.Index(i) + cmb.lp*cmb.gain)
 		}
 	}
 }
 
-// Loop records a signal by a given duration, repeating the recording
-// in subsequent playback.
-//
-// TODO cross-fade
-type Loop struct {
+// AllPass is a Schroeder allpass diffusing a signal without altering its magnitude response.
+type AllPass struct {
 	*mono
 	line *bufc
-	rec  bool
-
-	syncrec bool
-	sync    int
-	syncpos int
-}
-
-// NewLoop returns a Loop with sample buffer of a length approximated by d.
-func NewLoop(d time.Duration, in Sound) *Loop {
-	return &Loop{newmono(in), newbufc(Dtof(d, in.SampleRate()), 0), false, false, 0, 0}
-}
-
-// NewLoopFrames return a Loop with sample buffer of length nframes.
-func NewLoopFrames(nframes int, in Sound) *Loop {
-	return &Loop{newmono(in), newbufc(nframes, 0), false, false, 0, 0}
-}
-
-func (lp *Loop) SetBPM(bpm BPM) {
-	lp.sync = Dtof(bpm.Dur(), lp.SampleRate())
+	gain float64
 }
 
-func (lp *Loop) Syncing() bool { return lp.syncrec }
-
-func (lp *Loop) Recording() bool { return lp.rec }
-
-func (lp *Loop) Record() {
-	// TODO may want to replace lp.line with new instance instead?
-	// otherwise there will be left over data in buffer
-	// if adding Stop() method, unless that's desireable?
-	lp.line.w = 0
-	if lp.sync == 0 {
-		lp.rec = true
-	} else {
-		lp.syncpos = lp.sync
-		lp.syncrec = true
-	}
+func NewAllPass(gain float64, d time.Duration, in Sound) *AllPass {
+	return &AllPass{newmono(in), newbufc(Dtof(d, in.SampleRate()), 0), gain}
 }
 
-func (lp *Loop) Stop() {
-	lp.rec = false
-	lp.syncrec = false
-	lp.syncpos = 0
-	lp.line.r = 0
-}
-
-func (lp *Loop) Prepare(tc uint64) {
-	nf := tc * uint64(len(lp.out))
-	for i := range lp.out {
-		lp.out[i] = 0
-		if !lp.off {
-			if lp.syncrec {
-				if ((nf + uint64(i)) % uint64(lp.sync)) == 0 {
-					lp.syncrec = false
-					lp.rec = true
-				} else {
-					lp.syncpos--
-					if lp.syncpos == 0 {
-						log.Println("Failed to start loop.")
-						lp.syncrec = false
-					}
-				}
-			}
-
-			if lp.rec {
-				if done := lp.line.write(lp.in.Index(i)); done {
-					// lp.rec = false
-					// lp.line.r = 0
-					lp.Stop()
-				}
-			} else {
-				lp.out[i] = lp.line.read()
-			}
+func (ap *AllPass) Prepare(uint64) {
+	for i := range ap.out {
+		if ap.off {
+			ap.out[i] = 0
+		} else {
+			wd := ap.line.read()
+			w := ap.in.Index(i) + ap.gain*wd
+			ap.out[i] = wd - ap.gain*w
+			ap.line.write(w)
 		}
 	}
 }
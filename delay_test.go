@@ -1,8 +1,11 @@
 package snd
 
 import (
+	"math"
 	"testing"
 	"time"
+
+	"dasa.cc/signal"
 )
 
 func TestBufc(t *testing.T) {
@@ -44,6 +47,104 @@ func TestDtof(t *testing.T) {
 	}
 }
 
+func TestAllPass(t *testing.T) {
+	ap := NewAllPass(0.7, 5*time.Millisecond, newimpulse())
+	if e := energy(ap, 1, 200); !equaleps(e, 1, 0.001) {
+		t.Fatalf("impulse response energy have %v, want 1", e)
+	}
+}
+
+func TestCombDamp(t *testing.T) {
+	cmb := NewComb(0.9, 5*time.Millisecond, newimpulse())
+	e0 := energy(cmb, 10, 20)
+	cmb = NewComb(0.9, 5*time.Millisecond, newimpulse())
+	cmb.SetDamp(0.5)
+	if e1 := energy(cmb, 10, 20); e1 >= e0 {
+		t.Fatalf("damped comb energy %v not less than undamped %v", e1, e0)
+	}
+}
+
+func TestDelayTime(t *testing.T) {
+	const hz, d = 200, 10.37 // delay in frames
+	sr := DefaultSampleRate
+	for _, interp := range []Interpolation{InterpLinear, InterpAllPass, InterpCubic} {
+		tn := newtone(hz)
+		dly := NewDelay(10*time.Millisecond, tn)
+		dly.SetInterp(interp)
+		dly.SetTime(time.Duration(d/sr*float64(time.Second)), nil)
+		inps := GetInputs(dly)
+		for tc := 1; tc <= 20; tc++ {
+			for _, inp := range inps {
+				inp.sd.Prepare(uint64(tc))
+			}
+		}
+		for i, x := range dly.Samples() {
+			n := float64(19*len(dly.out)+i) - d
+			if want := math.Sin(n * Hertz(hz).Normalized(sr)); !equaleps(x, want, 0.001) {
+				t.Fatalf("interp %v have %v, want %v [i=%v]", interp, x, want, i)
+			}
+		}
+	}
+}
+
+func TestDelayModulation(t *testing.T) {
+	// constant modulation of one half places impulse at half the delay time
+	imp := newimpulse()
+	dly := NewDelay(4*time.Millisecond, imp)
+	dly.SetTime(time.Duration(176/dly.SampleRate()*float64(time.Second)), NewGain(0.5, newzeros()))
+	dly.time.y = dly.time.x // skip smoothing from time given at construction
+	want := 88
+	inps := GetInputs(dly)
+	for _, inp := range inps {
+		inp.sd.Prepare(1)
+	}
+	for i, x := range dly.Samples() {
+		if i == want && !equals(x, 1) || i != want && !equals(x, 0) {
+			t.Fatalf("have %v at frame %v, want impulse at frame %v", x, i, want)
+		}
+	}
+}
+
+func TestDelayLatency(t *testing.T) {
+	// whole frame delays are a frame less than their length
+	d := 3 * time.Millisecond
+	for _, sd := range []Sound{NewDelay(d, newimpulse()), NewComb(0, d, newimpulse())} {
+		want := Dtof(d, sd.SampleRate()) - 1
+		for _, inp := range GetInputs(sd) {
+			inp.sd.Prepare(1)
+		}
+		for i, x := range sd.Samples() {
+			if i == want && !equals(x, 1) || i != want && !equals(x, 0) {
+				t.Fatalf("%T have %v at frame %v, want impulse at frame %v", sd, x, i, want)
+			}
+		}
+	}
+}
+
+func TestTapOff(t *testing.T) {
+	// tap toggled off remains in phase with one never toggled
+	dly := NewDelay(20*time.Millisecond, newtone(440))
+	taps := []*Tap{NewTap(7*time.Millisecond, dly), NewTap(7*time.Millisecond, dly)}
+	for tc := 1; tc <= 10; tc++ {
+		if tc == 3 {
+			taps[1].Off()
+		}
+		if tc == 5 {
+			taps[1].On()
+		}
+		dly.in.Prepare(uint64(tc))
+		dly.Prepare(uint64(tc))
+		for _, tap := range taps {
+			tap.Prepare(uint64(tc))
+		}
+	}
+	for i, x := range taps[1].Samples() {
+		if want := taps[0].Samples()[i]; !equals(x, want) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+}
+
 func BenchmarkDelay(b *testing.B) {
 	dly := NewDelay(100*time.Millisecond, newunit())
 	b.ReportAllocs()
@@ -72,3 +173,16 @@ func BenchmarkComb(b *testing.B) {
 		cmb.Prepare(uint64(n))
 	}
 }
+
+func BenchmarkDelayMod(b *testing.B) {
+	dly := NewDelay(10*time.Millisecond, newunit())
+	mod := NewOscil(signal.Sine(), 2, nil)
+	dly.SetTime(5*time.Millisecond, mod)
+	dly.SetInterp(InterpCubic)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		mod.Prepare(uint64(n))
+		dly.Prepare(uint64(n))
+	}
+}
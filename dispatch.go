@@ -64,6 +64,7 @@ func GetInputs(sd Sound) []*Input {
 
 // TODO janky func
 func getinputs(sd Sound, wt int, out *[]*Input) {
+next:
 	for _, in := range sd.Inputs() {
 		if in == nil { // TODO for !realtime || in.IsOff() {
 			continue
@@ -72,7 +73,7 @@ func getinputs(sd Sound, wt int, out *[]*Input) {
 		for i, p := range *out {
 			if p.sd == in {
 				if p.wt >= wt {
-					return // object has or will be traversed on different path
+					continue next // object has or will be traversed on different path
 				}
 				at = i
 				break
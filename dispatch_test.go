@@ -57,6 +57,18 @@ func TestGetInputs(t *testing.T) {
 	}
 }
 
+func TestGetInputsShared(t *testing.T) {
+	// discovering x again must not skip inputs following it
+	x, y := newunit(), newunit()
+	mix := NewMixer(NewGain(1, x), x, y)
+	for _, inp := range GetInputs(mix) {
+		if inp.sd == y {
+			return
+		}
+	}
+	t.Fatal("input following shared input not discovered")
+}
+
 func BenchmarkGetInputs(b *testing.B) {
 	sd := mksound()
 	var inps []*Input
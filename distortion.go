@@ -0,0 +1,153 @@
+package snd
+
+import "math"
+
+// DistortionMode is the transfer function of a Distortion.
+type DistortionMode int
+
+const (
+	DistortionSoft DistortionMode = iota // hyperbolic tangent
+	DistortionTube                       // asymmetric soft clip adding even harmonics
+	DistortionHard                       // clipped to [-1..1]
+	DistortionFold                       // folded back from [-1..1]
+)
+
+// tubebias offsets input of DistortionTube so positive and negative halves clip unequally.
+const tubebias = 0.3
+
+// distort returns x mapped by transfer function of mode.
+func distort(mode DistortionMode, x float64) float64 {
+	switch mode {
+	case DistortionTube:
+		return math.Tanh(x+tubebias) - math.Tanh(tubebias)
+	case DistortionHard:
+		return clamp(x, -1, 1)
+	case DistortionFold:
+		m := math.Mod(x+1, 4)
+		if m < 0 {
+			m += 4
+		}
+		return 1 - math.Abs(m-2)
+	default:
+		return math.Tanh(x)
+	}
+}
+
+// Distortion saturates or clips input multiplied by drive.
+//
+// Transfer functions are calculated directly rather than sampled as with
+// Shaper. Output of DistortionTube is filtered of the offset its asymmetry
+// introduces.
+type Distortion struct {
+	*mono
+	mode  DistortionMode
+	drive param
+	level float64
+
+	ovs *oversampler
+	buf []float64
+
+	// dc blocker history
+	x1, y1 float64
+}
+
+func NewDistortion(mode DistortionMode, drive float64, in Sound) *Distortion {
+	dst := &Distortion{mono: newmono(in), mode: mode, level: 1}
+	dst.drive = newparam(drive, nil, dst.sr)
+	return dst
+}
+
+func (dst *Distortion) SetMode(mode DistortionMode) { dst.mode = mode }
+
+// SetDrive sets gain of input before distortion, multiplied by mod if not nil.
+func (dst *Distortion) SetDrive(drive float64, mod Sound) { dst.drive.set(drive, mod) }
+
+// SetLevel sets gain of output.
+func (dst *Distortion) SetLevel(db Decibel) { dst.level = db.Amp() }
+
+// SetOversample sets number of times input is oversampled before distortion,
+// rounded up to a power of two, to reduce aliasing. Oversampling delays output,
+// see Oversample.
+func (dst *Distortion) SetOversample(n int) {
+	if n <= 1 {
+		dst.ovs, dst.buf = nil, nil
+		return
+	}
+	dst.ovs = newoversampler(n)
+	dst.buf = make([]float64, dst.ovs.n)
+}
+
+func (dst *Distortion) Inputs() []Sound { return []Sound{dst.in, dst.drive.mod} }
+
+func (dst *Distortion) Prepare(uint64) {
+	// dc blocker pole for a cutoff of 10Hz
+	r := math.Exp(-twopi * 10 / dst.sr)
+	for i, x := range dst.in.Samples() {
+		drive := dst.drive.next(i)
+		if dst.off {
+			dst.out[i] = 0
+			continue
+		}
+		var y float64
+		if dst.ovs == nil {
+			y = distort(dst.mode, x*drive)
+		} else {
+			dst.ovs.up(x, dst.buf)
+			for j, u := range dst.buf {
+				dst.buf[j] = distort(dst.mode, u*drive)
+			}
+			y = dst.ovs.down(dst.buf)
+		}
+		if dst.mode == DistortionTube {
+			y, dst.x1 = y-dst.x1+r*dst.y1, y
+			dst.y1 = y
+		}
+		dst.out[i] = y * dst.level
+	}
+}
+
+// Bitcrusher reduces bit depth and sample rate of input.
+//
+// Sample rate is reduced by holding input without filtering so that aliasing
+// is heard. Bit depth may be fractional for smooth modulation.
+type Bitcrusher struct {
+	*mono
+	bits  float64
+	hz    float64
+	phase float64 // held input is sampled when reaching 1
+	held  float64
+}
+
+// NewBitcrusher returns Bitcrusher of in reduced to bits with sample rate unchanged.
+func NewBitcrusher(bits float64, in Sound) *Bitcrusher {
+	bc := &Bitcrusher{mono: newmono(in), phase: 1}
+	bc.SetBits(bits)
+	return bc
+}
+
+// SetBits sets bit depth where bits belongs to [1..32].
+func (bc *Bitcrusher) SetBits(bits float64) { bc.bits = clamp(bits, 1, 32) }
+
+// SetRate sets sample rate input is held at, or unchanged if zero.
+func (bc *Bitcrusher) SetRate(hz float64) { bc.hz = math.Max(hz, 0) }
+
+func (bc *Bitcrusher) Prepare(uint64) {
+	// levels either side of zero
+	q := math.Pow(2, bc.bits-1)
+	step := 1.0
+	if bc.hz != 0 && bc.hz < bc.sr {
+		step = bc.hz / bc.sr
+	}
+	for i, x := range bc.in.Samples() {
+		if bc.phase >= 1 {
+			bc.phase--
+			bc.held = x
+		}
+		bc.phase += step
+		if bc.off {
+			bc.out[i] = 0
+		} else {
+			bc.out[i] = clamp(math.Round(bc.held*q)/q, -1, 1)
+		}
+	}
+}
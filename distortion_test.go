@@ -0,0 +1,99 @@
+package snd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistort(t *testing.T) {
+	for _, td := range []struct {
+		mode    DistortionMode
+		x, want float64
+	}{
+		{DistortionSoft, 0.5, math.Tanh(0.5)},
+		{DistortionTube, 0, 0},
+		{DistortionHard, 0.5, 0.5},
+		{DistortionHard, -2, -1},
+		{DistortionFold, 0.5, 0.5},
+		{DistortionFold, 1.5, 0.5},
+		{DistortionFold, -1.25, -0.75},
+		{DistortionFold, 3.5, -0.5},
+	} {
+		if have := distort(td.mode, td.x); !equals(have, td.want) {
+			t.Errorf("mode %v at %v have %v, want %v", td.mode, td.x, have, td.want)
+		}
+	}
+	// tube clips positive half sooner
+	if p, n := distort(DistortionTube, 1), distort(DistortionTube, -1); p >= math.Abs(n) {
+		t.Errorf("have tube %v and %v, want asymmetry", p, n)
+	}
+}
+
+func TestDistortionTubeOffset(t *testing.T) {
+	dst := NewDistortion(DistortionTube, 4, newtone(440))
+	var sum float64
+	var count int
+	for tc := 1; tc <= 400; tc++ {
+		dst.in.Prepare(uint64(tc))
+		dst.Prepare(uint64(tc))
+		if tc > 300 {
+			for _, x := range dst.Samples() {
+				sum += x
+				count++
+			}
+		}
+	}
+	if mean := sum / float64(count); math.Abs(mean) > 0.01 {
+		t.Fatalf("have mean %v, want 0", mean)
+	}
+}
+
+func TestBitcrusher(t *testing.T) {
+	bc := NewBitcrusher(2, newtone(440))
+	bc.in.Prepare(1)
+	bc.Prepare(1)
+	for i, x := range bc.Samples() {
+		if want := math.Round(bc.in.Index(i)*2) / 2; x != want {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+
+	bc.SetBits(32)
+	bc.SetRate(bc.SampleRate() / 4)
+	bc.in.Prepare(2)
+	bc.Prepare(2)
+	for i, x := range bc.Samples() {
+		if want := bc.in.Index(i - i%4); !equaleps(x, want, 1e-9) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+}
+
+func BenchmarkDistortion(b *testing.B) {
+	dst := NewDistortion(DistortionTube, 4, newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		dst.Prepare(uint64(n))
+	}
+}
+
+func BenchmarkDistortionOversample(b *testing.B) {
+	dst := NewDistortion(DistortionTube, 4, newunit())
+	dst.SetOversample(4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		dst.Prepare(uint64(n))
+	}
+}
+
+func BenchmarkBitcrusher(b *testing.B) {
+	bc := NewBitcrusher(8, newunit())
+	bc.SetRate(8000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		bc.Prepare(uint64(n))
+	}
+}
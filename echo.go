@@ -0,0 +1,145 @@
+package snd
+
+import (
+	"math"
+	"time"
+)
+
+// MaxEcho is the greatest delay time of Echo.
+const MaxEcho = 4 * time.Second
+
+// EchoGlide is the time constant over which Echo glides to a new delay time.
+const EchoGlide = 50 * time.Millisecond
+
+// Echo is a stereo feedback delay with filtering in the feedback loop.
+//
+// In ping-pong mode input is summed into left channel and each repeat
+// alternates between left and right. Delay time changes glide over EchoGlide,
+// bending pitch of repeats rather than clicking. Repeats may be ducked while
+// input is present.
+type Echo struct {
+	*stereo
+	vt    [2]vtime
+	lines [2]*bufc
+	fb    float64
+
+	// feedback filters, disabled at zero hertz
+	lc, hc     [2]biquad
+	lchz, hchz float64
+	pingpong   bool
+	duck       follower
+	duckamt    float64
+	wet, dry   float64
+}
+
+func NewEcho(d time.Duration, in Sound) *Echo {
+	sr := in.SampleRate()
+	n := Dtof(MaxEcho, sr)
+	ec := &Echo{stereo: newstereo(in), fb: 0.5, wet: 1, dry: 1}
+	a := math.Exp(-1 / (EchoGlide.Seconds() * sr))
+	for c := range ec.lines {
+		ec.lines[c] = newbufc(n+2, 0)
+		ec.vt[c] = newvtime(d, n, sr)
+		ec.vt[c].time.a = a
+	}
+	ec.SetDuck(0, 250*time.Millisecond)
+	return ec
+}
+
+// SetTime sets delay between repeats, limited to MaxEcho.
+func (ec *Echo) SetTime(d time.Duration) {
+	for c := range ec.vt {
+		ec.vt[c].SetTime(d, nil)
+	}
+}
+
+// SetSync sets delay between repeats to note division div at bpm.
+func (ec *Echo) SetSync(bpm BPM, div Division) { ec.SetTime(bpm.Div(div)) }
+
+// SetFeedback sets amplitude of each repeat relative to the last where fb belongs to [0..1).
+func (ec *Echo) SetFeedback(fb float64) { ec.fb = clamp(fb, 0, 0.99) }
+
+// SetLowCut sets cutoff in hertz of highpass filter in feedback, or disables it if zero.
+func (ec *Echo) SetLowCut(hz float64) {
+	ec.lchz = hz
+	if hz > 0 {
+		w0 := Hertz(clamp(hz, 1, 0.49*ec.SampleRate())).Normalized(ec.SampleRate())
+		for c := range ec.lc {
+			ec.lc[c].set(BiquadHighPass, w0, math.Sqrt2/2, 0)
+		}
+	}
+}
+
+// SetHighCut sets cutoff in hertz of lowpass filter in feedback, or disables it if zero.
+func (ec *Echo) SetHighCut(hz float64) {
+	ec.hchz = hz
+	if hz > 0 {
+		w0 := Hertz(clamp(hz, 1, 0.49*ec.SampleRate())).Normalized(ec.SampleRate())
+		for c := range ec.hc {
+			ec.hc[c].set(BiquadLowPass, w0, math.Sqrt2/2, 0)
+		}
+	}
+}
+
+// SetPingPong sets whether repeats alternate between left and right.
+func (ec *Echo) SetPingPong(b bool) { ec.pingpong = b }
+
+// SetDuck attenuates repeats by up to amt, where amt belongs to [0..1], while
+// input is present, recovering over release once input stops.
+func (ec *Echo) SetDuck(amt float64, release time.Duration) {
+	ec.duckamt = clamp(amt, 0, 1)
+	ec.duck.set(time.Millisecond, release, ec.SampleRate())
+}
+
+// SetMix sets amplitude of repeats and original signal.
+func (ec *Echo) SetMix(wet, dry float64) { ec.wet, ec.dry = wet, dry }
+
+// filter returns x after feedback filters of channel c.
+func (ec *Echo) filter(c int, x float64) float64 {
+	if ec.lchz > 0 {
+		x = ec.lc[c].process(x)
+	}
+	if ec.hchz > 0 {
+		x = ec.hc[c].process(x)
+	}
+	return x
+}
+
+func (ec *Echo) Prepare(uint64) {
+	in := ec.in.Samples()
+	for i := range ec.l.out {
+		var l, r float64
+		if ec.in.Channels() == 2 {
+			l, r = in[i*2], in[i*2+1]
+		} else {
+			l, r = in[i], in[i]
+		}
+
+		yl := ec.filter(0, ec.vt[0].read(ec.lines[0], ec.lines[0].w, i))
+		yr := ec.filter(1, ec.vt[1].read(ec.lines[1], ec.lines[1].w, i))
+		if ec.pingpong {
+			ec.lines[0].write((l+r)/2 + ec.fb*yr)
+			ec.lines[1].write(ec.fb * yl)
+		} else {
+			ec.lines[0].write(l + ec.fb*yl)
+			ec.lines[1].write(r + ec.fb*yr)
+		}
+
+		g := ec.wet
+		if ec.duckamt > 0 {
+			g *= 1 - ec.duckamt*math.Min(ec.duck.next(math.Max(math.Abs(l), math.Abs(r))), 1)
+		}
+		if ec.l.off {
+			ec.l.out[i] = 0
+		} else {
+			ec.l.out[i] = l*ec.dry + yl*g
+		}
+		if ec.r.off {
+			ec.r.out[i] = 0
+		} else {
+			ec.r.out[i] = r*ec.dry + yr*g
+		}
+		ec.out[i*2] = ec.l.out[i]
+		ec.out[i*2+1] = ec.r.out[i]
+	}
+}
@@ -0,0 +1,87 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// frames returns n buffers of left and right output of sd.
+func frames(sd Sound, n int) (l, r []float64) {
+	inps := GetInputs(sd)
+	for tc := 1; tc <= n; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+		out := sd.Samples()
+		for i := 0; i < len(out); i += 2 {
+			l, r = append(l, out[i]), append(r, out[i+1])
+		}
+	}
+	return
+}
+
+func TestEchoPingPong(t *testing.T) {
+	ec := NewEcho(10*time.Millisecond, newimpulse())
+	ec.SetPingPong(true)
+	ec.SetMix(1, 0)
+	l, r := frames(ec, 8)
+	n := Dtof(10*time.Millisecond, ec.SampleRate())
+	for _, td := range []struct {
+		ch   []float64
+		at   int
+		want float64
+	}{
+		{l, n, 1}, {r, n, 0},
+		{r, 2 * n, 0.5}, {l, 2 * n, 0},
+		{l, 3 * n, 0.25}, {r, 3 * n, 0},
+	} {
+		if x := td.ch[td.at]; !equaleps(x, td.want, 0.001) {
+			t.Errorf("have %v at frame %v, want %v", x, td.at, td.want)
+		}
+	}
+}
+
+func TestEchoHighCut(t *testing.T) {
+	var amp [2]float64
+	for i, hz := range []float64{0, 1000} {
+		ec := NewEcho(5*time.Millisecond, newtone(8000))
+		ec.SetMix(1, 0)
+		ec.SetHighCut(hz)
+		amp[i] = amplitude(ec, 20, 20)
+	}
+	if amp[1] > amp[0]/10 {
+		t.Fatalf("high cut amplitude %v not attenuated from %v", amp[1], amp[0])
+	}
+}
+
+func TestEchoDuck(t *testing.T) {
+	ec := NewEcho(5*time.Millisecond, newtone(440))
+	ec.SetMix(1, 0)
+	ec.SetDuck(1, 100*time.Millisecond)
+	if a := amplitude(ec, 20, 20); a > 0.1 {
+		t.Fatalf("ducked amplitude have %v, want 0", a)
+	}
+}
+
+func TestEchoSync(t *testing.T) {
+	ec := NewEcho(time.Second, newunit())
+	ec.SetSync(120, Eighth)
+	want := Dtof(250*time.Millisecond, ec.SampleRate())
+	if d := ec.vt[0].time.x; math.Abs(d-float64(want)) > 1 {
+		t.Fatalf("have %v frames, want %v", d, want)
+	}
+}
+
+func BenchmarkEcho(b *testing.B) {
+	ec := NewEcho(300*time.Millisecond, newunit())
+	ec.SetPingPong(true)
+	ec.SetLowCut(200)
+	ec.SetHighCut(4000)
+	ec.SetDuck(0.5, 200*time.Millisecond)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ec.Prepare(uint64(n))
+	}
+}
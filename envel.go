@@ -1,6 +1,7 @@
 package snd
 
 import (
+	"math"
 	"time"
 
 	"dasa.cc/signal"
@@ -28,7 +29,7 @@ func newtimed(sig signal.Discrete, nfr int) *timed {
 	return &timed{sig, float64(nfr)}
 }
 
-// TODO look into exposing this
+// seq plays timed signals in order. See Breakpoints for the exposed form.
 type seq struct {
 	*mono
 	tms []*timed
@@ -36,10 +37,21 @@ type seq struct {
 	pn  float64
 
 	lk int
+
+	// once le is reached, playback continues from ls instead, calling wrap
+	// if not nil. A lock following le takes precedence.
+	ls, le int
+	wrap   func()
 }
 
 func newseq(in Sound) *seq {
-	return &seq{mono: newmono(in), lk: -1}
+	return &seq{mono: newmono(in), lk: -1, le: -1}
+}
+
+// value returns current output of sq before applying input.
+func (sq *seq) value() float64 {
+	tm := sq.tms[sq.r]
+	return tm.sig.At(sq.pn / tm.nfr)
 }
 
 func (sq *seq) Prepare(uint64) {
@@ -61,15 +73,22 @@ func (sq *seq) Prepare(uint64) {
 		sq.pn++
 		if sq.pn >= tm.nfr {
 			sq.pn = 0
-			sq.r++
-			if sq.r == len(sq.tms) {
+			if sq.r == sq.le && sq.lk != sq.r+1 {
+				sq.r = sq.ls
+				if sq.wrap != nil {
+					sq.wrap()
+				}
+			} else if sq.r++; sq.r == len(sq.tms) {
 				sq.r = 0
 			}
 		}
 	}
 }
 
-// TODO reimplement sustain functionality
+// ADSR is an envelope of timed segments that repeats once released.
+//
+// Sustain is a timed segment that may be locked with Sustain. For sustain
+// held by a gate and release from the current level, see GateADSR.
 type ADSR struct {
 	*seq
 	sustaining bool
@@ -125,6 +144,347 @@ func (adsr *ADSR) Release() (ok bool) {
 	return
 }
 
+// Curve is the shape of a transition between two levels.
+type Curve int
+
+const (
+	CurveLinear Curve = iota
+
+	// CurveExp changes quickly at first and slows approaching the target level.
+	CurveExp
+
+	// CurveLog changes slowly at first and quickens approaching the target level.
+	CurveLog
+
+	// CurveS eases in and out of the target level.
+	CurveS
+)
+
+// At returns progress of c at t belonging to [0..1] as a value of [0..1].
+func (c Curve) At(t float64) float64 {
+	switch c {
+	case CurveExp:
+		return 1 - expcurve(t)
+	case CurveLog:
+		return expcurve(1 - t)
+	case CurveS:
+		return (1 - math.Cos(math.Pi*t)) / 2
+	default:
+		return t
+	}
+}
+
+// Stage is a segment of a Breakpoints envelope moving to Level over Dur.
+type Stage struct {
+	Level float64
+	Dur   time.Duration
+	Curve Curve
+}
+
+// Breakpoints is a multi-segment envelope of arbitrary stages.
+//
+// Without a loop region, the envelope plays once and holds the level of its
+// last stage. A loop region repeats while the envelope has not been released,
+// each repeat continuing from the level of its last stage, and a sustain point
+// holds the level reached at the end of its stage until released. When both
+// are set, the sustain point takes effect once reached, including at the end
+// of the loop region.
+type Breakpoints struct {
+	*seq
+	start  float64
+	stages []Stage
+
+	sus      int
+	lf, lt   int
+	released bool
+}
+
+// NewBreakpoints returns envelope moving from start through stages. If in is
+// not nil, the envelope is applied as amplitude of in.
+func NewBreakpoints(start float64, stages []Stage, in Sound) *Breakpoints {
+	bp := &Breakpoints{seq: newseq(in), start: start, stages: stages, sus: -1, lf: -1, lt: -1}
+	sr := bp.SampleRate()
+	for _, st := range stages {
+		bp.tms = append(bp.tms, newtimed(make(signal.Discrete, 1024), Dtof(st.Dur, sr)))
+	}
+	bp.tms = append(bp.tms, newtimed(make(signal.Discrete, 2), 1))
+	for _, tm := range bp.tms {
+		if tm.nfr < 1 {
+			tm.nfr = 1
+		}
+	}
+	bp.wrap = func() {
+		// stage held after last or once released also wraps to itself
+		if bp.lf >= 0 && bp.r == bp.lf {
+			bp.fill(bp.lf, bp.stages[bp.lt].Level)
+		}
+	}
+	bp.Restart()
+	return bp
+}
+
+// fill samples stage i of bp from level a.
+func (bp *Breakpoints) fill(i int, a float64) {
+	tm := bp.tms[i]
+	if i == len(bp.stages) {
+		tm.sig[0], tm.sig[1] = a, a
+		return
+	}
+	st := bp.stages[i]
+	n := float64(len(tm.sig))
+	for j := range tm.sig {
+		tm.sig[j] = a + (st.Level-a)*st.Curve.At(float64(j)/n)
+	}
+}
+
+// SetSustain holds bp at the end of stage i until released. A negative i disables sustain.
+func (bp *Breakpoints) SetSustain(i int) {
+	if i >= len(bp.stages) {
+		i = -1
+	}
+	bp.sus = i
+	bp.lock()
+}
+
+// SetLoop repeats stages from through to until released. A negative from disables looping.
+func (bp *Breakpoints) SetLoop(from, to int) {
+	if from < 0 || to < from || to >= len(bp.stages) {
+		from, to = -1, -1
+	}
+	bp.lf, bp.lt = from, to
+	bp.lock()
+}
+
+// lock updates sustain and loop region of underlying seq.
+func (bp *Breakpoints) lock() {
+	hold := len(bp.stages)
+	bp.lk = -1
+	bp.ls, bp.le = hold, hold
+	if bp.released {
+		return
+	}
+	if bp.sus >= 0 {
+		bp.lk = bp.sus + 1
+	}
+	if bp.lf >= 0 {
+		bp.ls, bp.le = bp.lf, bp.lt
+	}
+}
+
+// Restart resets envelope to start from its first stage.
+func (bp *Breakpoints) Restart() {
+	lvl := bp.start
+	for i := range bp.tms {
+		bp.fill(i, lvl)
+		if i < len(bp.stages) {
+			lvl = bp.stages[i].Level
+		}
+	}
+	bp.r, bp.pn = 0, 0
+	bp.released = false
+	bp.lock()
+}
+
+// Release continues envelope past its sustain point or loop region. If the
+// sustain point has not been passed, envelope continues from its current
+// level with the stage following the sustain point.
+func (bp *Breakpoints) Release() {
+	if bp.released {
+		return
+	}
+	bp.released = true
+	if bp.sus >= 0 && bp.r <= bp.sus+1 {
+		lvl := bp.value()
+		bp.r, bp.pn = bp.sus+1, 0
+		bp.fill(bp.r, lvl)
+	}
+	bp.lock()
+}
+
+// EnvMode determines how an envelope responds to gate-on while already sounding.
+type EnvMode int
+
+const (
+	// Retrigger restarts attack from zero.
+	Retrigger EnvMode = iota
+
+	// Legato continues without restarting; attack resumes from the current
+	// level only if the envelope was releasing.
+	Legato
+
+	// Resume restarts attack from the current level.
+	Resume
+)
+
+type envstage int
+
+const (
+	envIdle envstage = iota
+	envAttack
+	envDecay
+	envSustain
+	envRelease
+)
+
+// expcurve returns an exponential fall from 1 to 0 as t goes from 0 to 1.
+func expcurve(t float64) float64 {
+	const k = 5
+	return (math.Exp(-k*t) - math.Exp(-k)) / (1 - math.Exp(-k))
+}
+
+// GateADSR is an envelope that holds sustain for as long as its gate is high.
+//
+// The gate is set with GateOn and GateOff, or read from a gate Sound where a
+// sample greater than zero is high. Attack is linear, decay and release are
+// exponential, and release always starts from the current level.
+type GateADSR struct {
+	*mono
+	atk, dcy, rel  float64 // frames
+	susamp, maxamp float64
+	mode           EnvMode
+
+	gate   bool
+	gatein Sound
+	high   bool
+
+	stage envstage
+	lvl   float64
+	from  float64 // level at start of stage
+	pn    float64 // frames into stage
+
+	trk Tracking
+	// amplitude, attack and decay multipliers from tracking, and those of
+	// SetNote latched until gate-on
+	ta, tatk, tdcy    float64
+	nta, ntatk, ntdcy float64
+}
+
+func NewGateADSR(attack, decay, release time.Duration, susamp, maxamp float64, in Sound) *GateADSR {
+	env := &GateADSR{mono: newmono(in), susamp: susamp, maxamp: maxamp, ta: 1, tatk: 1, tdcy: 1, nta: 1, ntatk: 1, ntdcy: 1}
+	sr := env.SampleRate()
+	env.atk = float64(Dtof(attack, sr))
+	env.dcy = float64(Dtof(decay, sr))
+	env.rel = float64(Dtof(release, sr))
+	return env
+}
+
+// SetMode sets response of env to gate-on while already sounding.
+func (env *GateADSR) SetMode(mode EnvMode) { env.mode = mode }
+
+// SetGate sets gate Sound of env; a nil gate restores use of GateOn and GateOff.
+func (env *GateADSR) SetGate(gate Sound) { env.gatein = gate }
+
+// GateOn starts env from attack depending on mode and holds sustain until GateOff.
+func (env *GateADSR) GateOn() { env.gate = true }
+
+// GateOff releases env from its current level.
+func (env *GateADSR) GateOff() { env.gate = false }
+
+// SetTracking sets how velocity and key of SetNote scale env.
+func (env *GateADSR) SetTracking(trk Tracking) { env.trk = trk }
+
+// SetNote scales levels and times of env by tracking from the next gate-on
+// that starts attack; a sounding note is left unchanged, as is one continued
+// by Legato.
+func (env *GateADSR) SetNote(key int, vel Velocity) {
+	env.nta = vel.Amp(env.trk.VelAmp)
+	env.ntatk = vel.Scale(env.trk.VelAttack)
+	env.ntdcy = KeyScale(key, env.trk.KeyCenter, env.trk.KeyDecay)
+}
+
+// NoteOn is a convenience for calling SetNote followed by GateOn.
+func (env *GateADSR) NoteOn(key int, vel Velocity) {
+	env.SetNote(key, vel)
+	env.GateOn()
+}
+
+// Active reports whether env is sounding, including release.
+func (env *GateADSR) Active() bool { return env.stage != envIdle }
+
+// Level returns current level of env.
+func (env *GateADSR) Level() float64 { return env.lvl }
+
+func (env *GateADSR) Inputs() []Sound { return []Sound{env.in, env.gatein} }
+
+func (env *GateADSR) trigger() {
+	switch {
+	case env.stage == envIdle || env.mode == Retrigger:
+		env.lvl = 0
+	case env.mode == Legato && env.stage != envRelease:
+		return
+	}
+	env.ta, env.tatk, env.tdcy = env.nta, env.ntatk, env.ntdcy
+	env.stage, env.pn = envAttack, 0
+}
+
+func (env *GateADSR) release() {
+	if env.stage != envIdle {
+		env.stage, env.from, env.pn = envRelease, env.lvl, 0
+	}
+}
+
+// step advances env by a single frame.
+func (env *GateADSR) step() {
+	maxamp, susamp := env.ta*env.maxamp, env.ta*env.susamp
+	switch env.stage {
+	case envAttack:
+		if atk := env.atk * env.tatk; atk < 1 {
+			env.lvl = maxamp
+		} else {
+			env.lvl += maxamp / atk
+		}
+		if env.lvl >= maxamp {
+			env.lvl = maxamp
+			env.stage, env.pn = envDecay, 0
+		}
+	case envDecay:
+		if dcy := env.dcy * env.tdcy; env.pn >= dcy {
+			env.lvl = susamp
+			env.stage = envSustain
+		} else {
+			env.lvl = susamp + (maxamp-susamp)*expcurve(env.pn/dcy)
+			env.pn++
+		}
+	case envSustain:
+		env.lvl = susamp
+	case envRelease:
+		if env.pn >= env.rel {
+			env.lvl = 0
+			env.stage = envIdle
+		} else {
+			env.lvl = env.from * expcurve(env.pn/env.rel)
+			env.pn++
+		}
+	}
+}
+
+func (env *GateADSR) Prepare(uint64) {
+	for i := range env.out {
+		high := env.gate
+		if env.gatein != nil {
+			high = env.gatein.Index(i) > 0
+		}
+		if high != env.high {
+			env.high = high
+			if high {
+				env.trigger()
+			} else {
+				env.release()
+			}
+		}
+
+		env.step()
+
+		if env.off {
+			env.out[i] = 0
+		} else if env.in == nil {
+			env.out[i] = env.lvl
+		} else {
+			env.out[i] = env.lvl * env.in.Index(i)
+		}
+	}
+}
+
 type Damp struct {
 	*mono
 	sig  signal.Discrete
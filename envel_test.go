@@ -1,6 +1,7 @@
 package snd
 
 import (
+	"math"
 	"testing"
 	"time"
 )
@@ -32,3 +33,159 @@ func BenchmarkDrive(b *testing.B) {
 		env.Prepare(uint64(n))
 	}
 }
+
+func TestGateADSR(t *testing.T) {
+	ms := time.Millisecond
+	env := NewGateADSR(ms, ms, 20*ms, 0.5, 1, nil)
+	env.GateOn()
+	for n := 1; n <= 100; n++ {
+		env.Prepare(uint64(n))
+	}
+	if x := env.Samples()[0]; !equals(x, 0.5) {
+		t.Fatalf("sustain not held, have %v, want 0.5", x)
+	}
+
+	env.GateOff()
+	env.Prepare(101)
+	rel := env.Level()
+	if rel <= 0 || rel >= 0.5 {
+		t.Fatalf("release level %v not within (0..0.5)", rel)
+	}
+
+	for _, td := range []struct {
+		mode EnvMode
+		low  bool
+	}{{Retrigger, true}, {Legato, false}, {Resume, false}} {
+		env.SetMode(td.mode)
+		env.GateOff()
+		env.Prepare(102)
+		lvl := env.Level()
+		env.GateOn()
+		env.Prepare(103)
+		if x := env.Samples()[0]; (x < lvl) != td.low {
+			t.Errorf("mode(%v) attack started at %v from level %v", td.mode, x, lvl)
+		}
+	}
+
+	env.GateOff()
+	for n := 104; n < 110; n++ {
+		env.Prepare(uint64(n))
+	}
+	if env.Active() || env.Level() != 0 {
+		t.Fatalf("envelope still active at level %v", env.Level())
+	}
+}
+
+func BenchmarkGateADSR(b *testing.B) {
+	ms := time.Millisecond
+	env := NewGateADSR(5*ms, 10*ms, 20*ms, 0.7, 1, nil)
+	env.SetGate(newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		env.Prepare(uint64(n))
+	}
+}
+
+func TestCurve(t *testing.T) {
+	for _, c := range []Curve{CurveLinear, CurveExp, CurveLog, CurveS} {
+		if x := c.At(0); !equals(x, 0) {
+			t.Errorf("curve(%v) at 0 have %v, want 0", c, x)
+		}
+		if x := c.At(1); !equals(x, 1) {
+			t.Errorf("curve(%v) at 1 have %v, want 1", c, x)
+		}
+	}
+	if CurveExp.At(0.25) <= 0.25 || CurveLog.At(0.25) >= 0.25 {
+		t.Error("exponential and logarithmic curves are not distinct")
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	ms := time.Millisecond
+	stages := []Stage{
+		{1, ms, CurveLinear},
+		{0.5, ms, CurveExp},
+		{0, 2 * ms, CurveS},
+	}
+
+	bp := NewBreakpoints(0, stages, nil)
+	bp.SetSustain(1)
+	for n := 1; n <= 4; n++ {
+		bp.Prepare(uint64(n))
+	}
+	if x := bp.Samples()[0]; !equals(x, 0.5) {
+		t.Fatalf("sustain not held, have %v, want 0.5", x)
+	}
+	bp.Release()
+	for n := 5; n <= 8; n++ {
+		bp.Prepare(uint64(n))
+	}
+	if x := bp.Samples()[0]; !equals(x, 0) {
+		t.Fatalf("released envelope did not end, have %v, want 0", x)
+	}
+
+	bp = NewBreakpoints(0, stages, nil)
+	bp.SetLoop(0, 1)
+	var max float64
+	for n := 1; n <= 8; n++ {
+		bp.Prepare(uint64(n))
+		if n > 4 {
+			for _, x := range bp.Samples() {
+				if x > max {
+					max = x
+				}
+			}
+		}
+	}
+	if max < 0.9 {
+		t.Fatalf("loop region did not repeat, max of %v", max)
+	}
+}
+
+func TestBreakpointsLoopWrap(t *testing.T) {
+	ms := time.Millisecond
+	stages := []Stage{
+		{1, ms, CurveLinear},
+		{0.5, ms, CurveLinear},
+		{0, ms, CurveLinear},
+	}
+
+	// repeats continue from level of last stage of loop region without a jump
+	bp := NewBreakpoints(0, stages, nil)
+	bp.SetLoop(0, 1)
+	var prv float64
+	for n := 1; n <= 4; n++ {
+		bp.Prepare(uint64(n))
+		for i, x := range bp.Samples() {
+			if math.Abs(x-prv) > 0.1 {
+				t.Fatalf("have jump from %v to %v [n=%v i=%v]", prv, x, n, i)
+			}
+			prv = x
+		}
+	}
+
+	// sustain at end of loop region holds rather than repeating
+	bp = NewBreakpoints(0, stages, nil)
+	bp.SetLoop(0, 1)
+	bp.SetSustain(1)
+	for n := 1; n <= 4; n++ {
+		bp.Prepare(uint64(n))
+	}
+	for _, x := range bp.Samples() {
+		if !equals(x, 0.5) {
+			t.Fatalf("sustain not held, have %v, want 0.5", x)
+		}
+	}
+}
+
+func BenchmarkBreakpoints(b *testing.B) {
+	ms := time.Millisecond
+	bp := NewBreakpoints(0, []Stage{{1, 5 * ms, CurveLog}, {0.7, 10 * ms, CurveExp}, {0, 20 * ms, CurveS}}, newunit())
+	bp.SetLoop(0, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		bp.Prepare(uint64(n))
+	}
+}
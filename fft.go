@@ -0,0 +1,127 @@
+package snd
+
+import (
+	"math"
+	"math/bits"
+)
+
+// fftplan holds precomputed tables for radix-2 transforms of length n.
+type fftplan struct {
+	n   int
+	rev []int
+	tw  []complex128
+}
+
+// newfftplan returns plan for transforms of length n where n is a power of 2.
+func newfftplan(n int) *fftplan {
+	p := &fftplan{n: n, rev: make([]int, n), tw: make([]complex128, n/2)}
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range p.rev {
+		p.rev[i] = int(bits.Reverse64(uint64(i)) >> shift)
+	}
+	for i := range p.tw {
+		sin, cos := math.Sincos(-twopi * float64(i) / float64(n))
+		p.tw[i] = complex(cos, sin)
+	}
+	return p
+}
+
+// transform computes in place the discrete Fourier transform of x, or its
+// inverse scaled by 1/n, where len(x) equals the plan's length.
+func (p *fftplan) transform(x []complex128, inverse bool) {
+	for i, j := range p.rev {
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= p.n; size <<= 1 {
+		half, step := size/2, p.n/size
+		for start := 0; start < p.n; start += size {
+			for k := 0; k < half; k++ {
+				w := p.tw[k*step]
+				if inverse {
+					w = complex(real(w), -imag(w))
+				}
+				a, b := x[start+k], w*x[start+k+half]
+				x[start+k], x[start+k+half] = a+b, a-b
+			}
+		}
+	}
+	if inverse {
+		s := complex(1/float64(p.n), 0)
+		for i := range x {
+			x[i] *= s
+		}
+	}
+}
+
+// convolver is a uniformly partitioned overlap-save FFT convolution with a
+// partition size equal to the block size so no latency is introduced.
+type convolver struct {
+	plan *fftplan
+	b    int
+
+	h   [][]complex128 // spectra of kernel partitions
+	fdl [][]complex128 // frequency-domain delay line of input spectra
+	pos int
+
+	win []float64 // previous and current input block
+	acc []complex128
+}
+
+// newconvolver returns convolver of kernel h processing blocks of length b.
+func newconvolver(h []float64, b int) *convolver {
+	n := 2 * b
+	np := (len(h) + b - 1) / b
+	if np == 0 {
+		np = 1
+	}
+	cv := &convolver{
+		plan: newfftplan(n),
+		b:    b,
+		h:    make([][]complex128, np),
+		fdl:  make([][]complex128, np),
+		win:  make([]float64, n),
+		acc:  make([]complex128, n),
+	}
+	for p := range cv.h {
+		cv.h[p] = make([]complex128, n)
+		cv.fdl[p] = make([]complex128, n)
+		for i := 0; i < b && p*b+i < len(h); i++ {
+			cv.h[p][i] = complex(h[p*b+i], 0)
+		}
+		cv.plan.transform(cv.h[p], false)
+	}
+	return cv
+}
+
+// process convolves block x writing the result to y, both of length b.
+func (cv *convolver) process(x, y []float64) {
+	copy(cv.win, cv.win[cv.b:])
+	copy(cv.win[cv.b:], x)
+
+	cv.pos--
+	if cv.pos < 0 {
+		cv.pos = len(cv.fdl) - 1
+	}
+	X := cv.fdl[cv.pos]
+	for i, v := range cv.win {
+		X[i] = complex(v, 0)
+	}
+	cv.plan.transform(X, false)
+
+	for i := range cv.acc {
+		cv.acc[i] = 0
+	}
+	for p, H := range cv.h {
+		X := cv.fdl[(cv.pos+p)%len(cv.fdl)]
+		for i, h := range H {
+			cv.acc[i] += X[i] * h
+		}
+	}
+	cv.plan.transform(cv.acc, true)
+
+	for i := range y {
+		y[i] = real(cv.acc[cv.b+i])
+	}
+}
@@ -0,0 +1,72 @@
+package snd
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	const n = 64
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(rand.Float64()*2-1, 0)
+	}
+	have := append([]complex128(nil), x...)
+	newfftplan(n).transform(have, false)
+	for k := range have {
+		var want complex128
+		for i, v := range x {
+			want += v * cmplx.Exp(complex(0, -twopi*float64(k*i)/n))
+		}
+		if cmplx.Abs(have[k]-want) > epsilon {
+			t.Fatalf("bin %v have %v, want %v", k, have[k], want)
+		}
+	}
+	newfftplan(n).transform(have, true)
+	for i := range x {
+		if cmplx.Abs(have[i]-x[i]) > epsilon {
+			t.Fatalf("inverse %v have %v, want %v", i, have[i], x[i])
+		}
+	}
+}
+
+func TestConvolver(t *testing.T) {
+	const b = 16
+	h := make([]float64, 37)
+	for i := range h {
+		h[i] = rand.Float64()*2 - 1
+	}
+	x := make([]float64, b*8)
+	for i := range x {
+		x[i] = rand.Float64()*2 - 1
+	}
+
+	cv := newconvolver(h, b)
+	have := make([]float64, len(x))
+	for i := 0; i < len(x); i += b {
+		cv.process(x[i:i+b], have[i:i+b])
+	}
+	for i := range x {
+		var want float64
+		for k, c := range h {
+			if i-k >= 0 {
+				want += c * x[i-k]
+			}
+		}
+		if math.Abs(have[i]-want) > epsilon {
+			t.Fatalf("sample %v have %v, want %v", i, have[i], want)
+		}
+	}
+}
+
+func BenchmarkFFT(b *testing.B) {
+	x := make([]complex128, 512)
+	p := newfftplan(len(x))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		p.transform(x, false)
+	}
+}
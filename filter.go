@@ -4,9 +4,17 @@ import "math"
 
 // LowPass is a 3rd order IIR filter.
 //
-// Recursive implementation of the Gaussian filter.
+// Recursive implementation of the Gaussian filter. The cutoff frequency may be
+// modulated, in which case coefficients are updated every frame or at an
+// interval set by SetUpdateRate. Changes to frequency are smoothed over
+// ParamSmoothing.
 type LowPass struct {
 	*mono
+	freq param
+
+	// frames between coefficient updates and frames since last
+	rate, k int
+	dirty   bool
 
 	// normalization factor
 	b float64
@@ -23,8 +31,33 @@ func (lp *LowPass) SetPassthrough(b bool) { lp.passthrough = b }
 func (lp *LowPass) Passthrough() bool     { return lp.passthrough }
 
 func NewLowPass(freq float64, in Sound) *LowPass {
+	lp := &LowPass{mono: newmono(in), freq: newparam(freq, nil, in.SampleRate()), rate: 1}
+	lp.set(freq)
+	return lp
+}
+
+// SetFreq sets cutoff frequency in hertz, multiplied by mod if not nil.
+func (lp *LowPass) SetFreq(hz float64, mod Sound) {
+	lp.freq.set(hz, mod)
+	lp.dirty = true
+}
+
+// SetUpdateRate sets number of frames between coefficient updates while
+// modulated or smoothing, trading accuracy for performance.
+func (lp *LowPass) SetUpdateRate(n int) {
+	if n < 1 {
+		n = 1
+	}
+	lp.rate = n
+}
+
+func (lp *LowPass) Inputs() []Sound { return []Sound{lp.in, lp.freq.mod} }
+
+// set calculates coefficients for cutoff freq.
+func (lp *LowPass) set(freq float64) {
+	sr := lp.in.SampleRate()
 	q := 5.0
-	s := in.SampleRate() / freq / q
+	s := sr / clamp(freq, 1, sr/2) / q
 
 	if s > 2.5 {
 		q = 0.98711*s - 0.96330
@@ -46,11 +79,19 @@ func NewLowPass(freq float64, in Sound) *LowPass {
 	b2 *= b0
 	b3 *= b0
 
-	return &LowPass{mono: newmono(in), b: b, b0: b0, b1: b1, b2: b2, b3: b3}
+	lp.b, lp.b0, lp.b1, lp.b2, lp.b3 = b, b0, b1, b2, b3
 }
 
 func (lp *LowPass) Prepare(uint64) {
 	for i, x := range lp.in.Samples() {
+		if lp.dirty || !lp.freq.settled() {
+			hz := lp.freq.next(i)
+			if lp.k++; lp.k >= lp.rate || lp.freq.settled() {
+				lp.k, lp.dirty = 0, false
+				lp.set(hz)
+			}
+		}
+
 		if lp.off {
 			lp.out[i] = 0
 		} else if lp.passthrough {
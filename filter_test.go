@@ -1,6 +1,20 @@
 package snd
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
+
+func TestLowPassModulation(t *testing.T) {
+	lp := NewLowPass(1000, newtone(440))
+	lfo := NewLFO(LFOSine, 1000)
+	lfo.SetUnipolar(true)
+	lfo.SetDepth(2, 0.1)
+	lp.SetFreq(1000, lfo)
+	if x := amplitude(lp, 0, 256); math.IsNaN(x) || x > 1.5 {
+		t.Fatalf("audio-rate modulation unstable with amplitude %v", x)
+	}
+}
 
 func BenchmarkLowPass(b *testing.B) {
 	lp := NewLowPass(500, newunit())
@@ -10,3 +24,14 @@ func BenchmarkLowPass(b *testing.B) {
 		lp.Prepare(uint64(n))
 	}
 }
+
+func BenchmarkLowPassMod(b *testing.B) {
+	lp := NewLowPass(500, newunit())
+	lp.SetFreq(500, NewLFO(LFOSine, 2))
+	lp.SetUpdateRate(16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		lp.Prepare(uint64(n))
+	}
+}
@@ -0,0 +1,164 @@
+package snd
+
+import (
+	"math"
+
+	"dasa.cc/signal"
+)
+
+// FIRDirectMax is the longest kernel an FIR convolves directly; longer
+// kernels are convolved by FFT in blocks.
+const FIRDirectMax = 64
+
+// FIR is a finite impulse response filter convolving input with a kernel of coefficients.
+//
+// Kernels designed by LowPassFIR, HighPassFIR and BandPassFIR are symmetric,
+// resulting in linear phase with a delay of half the kernel length.
+type FIR struct {
+	*mono
+	h signal.Discrete
+
+	// direct form history, written twice to avoid wrapping
+	hist []float64
+	w    int
+
+	cv *convolver
+}
+
+// NewFIR returns FIR of in convolved with kernel h; an empty kernel outputs silence.
+func NewFIR(h signal.Discrete, in Sound) *FIR {
+	if len(h) == 0 {
+		h = signal.Discrete{0}
+	}
+	f := &FIR{mono: newmono(in), h: h}
+	if len(h) > FIRDirectMax {
+		f.cv = newconvolver(h, len(f.out))
+	} else {
+		f.hist = make([]float64, 2*len(h))
+	}
+	return f
+}
+
+func (f *FIR) Prepare(uint64) {
+	if f.off {
+		for i := range f.out {
+			f.out[i] = 0
+		}
+		return
+	}
+	if f.cv != nil {
+		f.cv.process(f.in.Samples(), f.out)
+		return
+	}
+	n := len(f.h)
+	for i, x := range f.in.Samples() {
+		f.w--
+		if f.w < 0 {
+			f.w = n - 1
+		}
+		f.hist[f.w], f.hist[f.w+n] = x, x
+
+		var y float64
+		for k, h := range f.h {
+			y += h * f.hist[f.w+k]
+		}
+		f.out[i] = y
+	}
+}
+
+// Window returns the weight of sample i of n for a window function.
+type Window func(i, n int) float64
+
+func HannWindow(i, n int) float64 {
+	if n == 1 {
+		return 1
+	}
+	return 0.5 - 0.5*math.Cos(twopi*float64(i)/float64(n-1))
+}
+
+func BlackmanWindow(i, n int) float64 {
+	if n == 1 {
+		return 1
+	}
+	t := twopi * float64(i) / float64(n-1)
+	return 0.42 - 0.5*math.Cos(t) + 0.08*math.Cos(2*t)
+}
+
+// KaiserWindow returns a Kaiser window where beta trades main lobe width for side lobe level.
+func KaiserWindow(beta float64) Window {
+	return func(i, n int) float64 {
+		if n == 1 {
+			return 1
+		}
+		t := 2*float64(i)/float64(n-1) - 1
+		return besseli0(beta*math.Sqrt(1-t*t)) / besseli0(beta)
+	}
+}
+
+// besseli0 returns the zeroth order modified Bessel function of the first kind.
+func besseli0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 64; k++ {
+		term *= (x / 2) * (x / 2) / float64(k*k)
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+// sinc returns n coefficients of a windowed-sinc lowpass at cutoff fc normalized to sample rate.
+func sinc(n int, fc float64, win Window) signal.Discrete {
+	h := make(signal.Discrete, n)
+	m := float64(n-1) / 2
+	for i := range h {
+		t := float64(i) - m
+		if t == 0 {
+			h[i] = 2 * fc
+		} else {
+			h[i] = math.Sin(twopi*fc*t) / (math.Pi * t)
+		}
+		h[i] *= win(i, n)
+	}
+	return h
+}
+
+// oddlen returns n, or n+1 if n is even, so a kernel has a center tap.
+func oddlen(n int) int { return n | 1 }
+
+// LowPassFIR returns coefficients of a windowed-sinc lowpass at cutoff hz for
+// sample rate sr normalized to unity gain at 0Hz. Length n is made odd.
+func LowPassFIR(n int, hz, sr float64, win Window) signal.Discrete {
+	h := sinc(oddlen(n), hz/sr, win)
+	var sum float64
+	for _, x := range h {
+		sum += x
+	}
+	for i := range h {
+		h[i] /= sum
+	}
+	return h
+}
+
+// HighPassFIR returns coefficients of a windowed-sinc highpass at cutoff hz for
+// sample rate sr by spectral inversion of a lowpass. Length n is made odd.
+func HighPassFIR(n int, hz, sr float64, win Window) signal.Discrete {
+	h := LowPassFIR(n, hz, sr, win)
+	for i := range h {
+		h[i] = -h[i]
+	}
+	h[len(h)/2] += 1
+	return h
+}
+
+// BandPassFIR returns coefficients of a windowed-sinc bandpass between lo and
+// hi hertz for sample rate sr. Length n is made odd.
+func BandPassFIR(n int, lo, hi, sr float64, win Window) signal.Discrete {
+	h := LowPassFIR(n, hi, sr, win)
+	l := LowPassFIR(n, lo, sr, win)
+	for i := range h {
+		h[i] -= l[i]
+	}
+	return h
+}
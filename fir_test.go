@@ -0,0 +1,72 @@
+package snd
+
+import (
+	"testing"
+
+	"dasa.cc/signal"
+)
+
+func TestFIRDirect(t *testing.T) {
+	// three tap moving sum of a constant settles to three times input
+	f := NewFIR(signal.Discrete{1, 1, 1}, newunit())
+	f.Prepare(1)
+	if x := f.Samples()[0]; !equals(x, DefaultAmpFac) {
+		t.Fatalf("first sample have %v, want %v", x, DefaultAmpFac)
+	}
+	if x := f.Samples()[2]; !equals(x, 3*DefaultAmpFac) {
+		t.Fatalf("settled sample have %v, want %v", x, 3*DefaultAmpFac)
+	}
+}
+
+func TestFIRDesign(t *testing.T) {
+	sr := DefaultSampleRate
+	tests := []struct {
+		h    signal.Discrete
+		hz   float64
+		want float64
+	}{
+		{LowPassFIR(31, 2000, sr, HannWindow), 200, 1},
+		{LowPassFIR(31, 2000, sr, HannWindow), 12000, 0},
+		{LowPassFIR(255, 2000, sr, KaiserWindow(8)), 200, 1},
+		{LowPassFIR(255, 2000, sr, KaiserWindow(8)), 4000, 0},
+		{HighPassFIR(255, 2000, sr, BlackmanWindow), 200, 0},
+		{HighPassFIR(255, 2000, sr, BlackmanWindow), 8000, 1},
+		{BandPassFIR(511, 1000, 4000, sr, BlackmanWindow), 2000, 1},
+		{BandPassFIR(511, 1000, 4000, sr, BlackmanWindow), 200, 0},
+		{BandPassFIR(511, 1000, 4000, sr, BlackmanWindow), 10000, 0},
+	}
+	for i, test := range tests {
+		f := NewFIR(test.h, newtone(test.hz))
+		if have := amplitude(f, 64, 64); !equaleps(have, test.want, 0.01) {
+			t.Errorf("tests[%v] at %vHz have %v, want %v", i, test.hz, have, test.want)
+		}
+	}
+}
+
+func TestFIREmpty(t *testing.T) {
+	f := NewFIR(nil, newunit())
+	f.Prepare(1)
+	for _, x := range f.Samples() {
+		if x != 0 {
+			t.Fatalf("have %v, want 0", x)
+		}
+	}
+}
+
+func BenchmarkFIRDirect(b *testing.B) {
+	f := NewFIR(LowPassFIR(FIRDirectMax-1, 1000, DefaultSampleRate, HannWindow), newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		f.Prepare(uint64(n))
+	}
+}
+
+func BenchmarkFIRConvolve(b *testing.B) {
+	f := NewFIR(LowPassFIR(4095, 1000, DefaultSampleRate, KaiserWindow(8)), newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		f.Prepare(uint64(n))
+	}
+}
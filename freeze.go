@@ -2,48 +2,198 @@ package snd
 
 import (
 	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"dasa.cc/signal"
 )
 
+var (
+	// freezesem limits number of freezes rendering at once and is guarded by freezemu.
+	freezesem = make(chan struct{}, runtime.NumCPU())
+	freezemu  sync.Mutex
+)
+
+// SetFreezeConcurrency sets greatest number of freezes rendered in parallel.
+// Renders already started are limited as before.
+func SetFreezeConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	freezemu.Lock()
+	freezesem = make(chan struct{}, n)
+	freezemu.Unlock()
+}
+
+// Freeze repeats a rendering of its input of a given duration.
+//
+// Input is rendered in the background and Freeze is silent until ready, or
+// input may instead be captured live, playing through as it is captured.
+// Input rendered in the background must not also be part of another graph
+// being prepared.
 type Freeze struct {
 	*mono
-	sig, prv signal.Discrete
-	r        int
+	src Sound
+	n   int // length in frames
+	sig atomic.Pointer[signal.Discrete]
+	r   int
+
+	// frames rendered of current rendering and its notification, guarded
+	// by mu with generation of current rendering and capture if live
+	progress atomic.Int64
+	mu       sync.Mutex
+	gen      uint64
+	done     chan struct{}
+
+	// serialises renders of src
+	rmu sync.Mutex
+
+	// frames captured if live
+	live bool
+	w    int
+	cap  signal.Discrete
 }
 
+// NewFreeze returns Freeze of duration d rendering in in the background.
 func NewFreeze(d time.Duration, in Sound) *Freeze {
-	f := Dtof(d, in.SampleRate())
+	frz := &Freeze{mono: newmono(nil), src: in, n: Dtof(d, in.SampleRate())}
+	frz.Refreeze()
+	return frz
+}
 
-	n := f
-	if n == 0 || n&(n-1) != 0 {
-		_, e := math.Frexp(float64(n))
-		n = int(math.Ldexp(1, e))
+// NewFreezeLive returns Freeze of duration d capturing in as it is prepared,
+// passing input through until capture is complete.
+func NewFreezeLive(d time.Duration, in Sound) *Freeze {
+	frz := &Freeze{mono: newmono(in), n: Dtof(d, in.SampleRate()), live: true}
+	frz.Refreeze()
+	return frz
+}
+
+// NewFreezeSignal returns Freeze repeating sig, such as one returned by TimeStretch.
+func NewFreezeSignal(sig signal.Discrete) *Freeze {
+	frz := &Freeze{mono: newmono(nil), n: len(sig), done: make(chan struct{})}
+	frz.sig.Store(&sig)
+	frz.progress.Store(int64(len(sig)))
+	close(frz.done)
+	return frz
+}
+
+// Refreeze renders input again, such as after its parameters have changed.
+// The previous rendering, if any, continues playing until ready. A rendering
+// not yet complete is abandoned and its Done channel closed.
+func (frz *Freeze) Refreeze() {
+	frz.mu.Lock()
+	defer frz.mu.Unlock()
+	if frz.done != nil {
+		select {
+		case <-frz.done:
+		default:
+			close(frz.done)
+		}
 	}
+	frz.gen++
+	frz.done = make(chan struct{})
+	frz.progress.Store(0)
 
-	frz := &Freeze{mono: newmono(nil), prv: make(signal.Discrete, n)}
-	frz.sig = frz.prv[:f]
+	if frz.live {
+		frz.cap, frz.w = make(signal.Discrete, frz.n), 0
+		return
+	}
+	go frz.render(frz.gen, frz.done)
+}
 
-	inps := GetInputs(in)
-	dp := new(Dispatcher)
+// current reports whether gen is generation of current rendering, storing
+// progress p of it if so.
+func (frz *Freeze) current(gen uint64, p int) bool {
+	frz.mu.Lock()
+	defer frz.mu.Unlock()
+	if frz.gen != gen {
+		return false
+	}
+	frz.progress.Store(int64(p))
+	return true
+}
+
+// render dispatches input of frz into a new signal, storing it when complete
+// unless superseded by generation of a later rendering.
+func (frz *Freeze) render(gen uint64, done chan struct{}) {
+	frz.rmu.Lock()
+	defer frz.rmu.Unlock()
+	if !frz.current(gen, 0) {
+		return
+	}
+	freezemu.Lock()
+	sem := freezesem
+	freezemu.Unlock()
+	sem <- struct{}{}
+	defer func() { <-sem }()
 
-	// t := time.Now()
-	buflen := len(in.Samples())
-	for i := 0; i < n; i += buflen {
+	// round up to whole buffers
+	buflen := len(frz.src.Samples())
+	sig := make(signal.Discrete, (frz.n+buflen-1)/buflen*buflen)[:frz.n]
+
+	inps := GetInputs(frz.src)
+	dp := new(Dispatcher)
+	for i := 0; i < frz.n; i += buflen {
 		dp.Dispatch(1, inps...)
-		ringcopy(frz.sig[i:i+buflen], in.Samples(), 0)
+		copy(sig[i:i+buflen], frz.src.Samples())
+		if !frz.current(gen, i+buflen) {
+			return
+		}
 	}
-	// log.Println("freeze took", time.Now().Sub(t))
-	return frz
+
+	frz.mu.Lock()
+	defer frz.mu.Unlock()
+	if frz.gen != gen {
+		return
+	}
+	frz.progress.Store(int64(frz.n))
+	frz.sig.Store(&sig)
+	close(done)
+}
+
+// Signal returns the frozen signal, or nil if not yet ready.
+func (frz *Freeze) Signal() signal.Discrete {
+	if sig := frz.sig.Load(); sig != nil {
+		return *sig
+	}
+	return nil
+}
+
+// Done returns channel closed when current rendering is complete.
+func (frz *Freeze) Done() <-chan struct{} {
+	frz.mu.Lock()
+	defer frz.mu.Unlock()
+	return frz.done
+}
+
+// Wait blocks until current rendering is complete.
+func (frz *Freeze) Wait() { <-frz.Done() }
+
+// Progress returns portion of current rendering complete belonging to [0..1].
+func (frz *Freeze) Progress() float64 {
+	if frz.n == 0 {
+		return 1
+	}
+	return math.Min(float64(frz.progress.Load())/float64(frz.n), 1)
 }
 
 func (frz *Freeze) Restart() { frz.r = 0 }
 
 var empty = make([]float64, 256)
 
+// ringcopy fills dst from src repeating from index r, returning index following
+// the last copied. An empty src fills dst with zeros.
 func ringcopy(dst, src []float64, r int) int {
 	dn, sn := len(dst), len(src)
+	if sn == 0 {
+		for i := range dst {
+			dst[i] = 0
+		}
+		return 0
+	}
 	for w := 0; w < dn; {
 		x := copy(dst[w:], src[r:])
 		w += x
@@ -61,22 +211,53 @@ func (frz *Freeze) Off() {
 }
 
 func (frz *Freeze) Prepare(uint64) {
-	if frz.off {
-		n := len(frz.out)
-		frz.r = (frz.r + n) & (n - 1)
+	if frz.live && frz.capture() {
+		return
+	}
+
+	sig := frz.Signal()
+	if frz.off || len(sig) == 0 {
+		copy(frz.out, empty)
+		if len(sig) != 0 {
+			frz.r = (frz.r + len(frz.out)) % len(sig)
+		}
 	} else {
-		frz.r = ringcopy(frz.out, frz.sig, frz.r)
-	}
-
-	// for i := range frz.out {
-	// if frz.off {
-	// frz.out[i] = 0
-	// } else {
-	// frz.out[i] = frz.sig[frz.pos]
-	// frz.pos++
-	// if frz.pos == frz.nfr {
-	// frz.pos = 0
-	// }
-	// }
-	// }
+		frz.r = ringcopy(frz.out, sig, frz.r)
+	}
+}
+
+// capture passes input through while writing it to signal of frz, repeating
+// the signal from the frame capture completes. It reports false if not capturing.
+func (frz *Freeze) capture() bool {
+	frz.mu.Lock()
+	defer frz.mu.Unlock()
+	if frz.cap == nil {
+		return false
+	}
+	in := frz.in.Samples()
+	i := 0
+	for ; i < len(in) && frz.w < len(frz.cap); i++ {
+		if frz.off {
+			frz.out[i] = 0
+		} else {
+			frz.out[i] = in[i]
+		}
+		frz.cap[frz.w] = in[i]
+		frz.w++
+	}
+	frz.progress.Store(int64(frz.w))
+	if frz.w < len(frz.cap) {
+		return true
+	}
+
+	sig := frz.cap
+	frz.cap = nil
+	frz.sig.Store(&sig)
+	close(frz.done)
+	if frz.off || len(sig) == 0 {
+		copy(frz.out[i:], empty)
+	} else {
+		frz.r = ringcopy(frz.out[i:], sig, 0)
+	}
+	return true
 }
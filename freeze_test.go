@@ -1,6 +1,8 @@
 package snd
 
 import (
+	"math"
+	"runtime"
 	"testing"
 	"time"
 
@@ -34,6 +36,25 @@ func TestRingcopy(t *testing.T) {
 	}
 }
 
+func TestFreezeSignalEmpty(t *testing.T) {
+	if r := ringcopy([]float64{1, 2}, nil, 0); r != 0 {
+		t.Fatalf("have %v, want 0", r)
+	}
+	for _, sig := range []signal.Discrete{nil, {}} {
+		frz := NewFreezeSignal(sig)
+		frz.Wait()
+		frz.Prepare(1)
+		for i, x := range frz.Samples() {
+			if x != 0 {
+				t.Fatalf("have %v, want 0 [i=%v]", x, i)
+			}
+		}
+		if p := frz.Progress(); p != 1 {
+			t.Fatalf("have progress %v, want 1", p)
+		}
+	}
+}
+
 func BenchmarkRingcopy(b *testing.B) {
 	// src := []float64(ExpDecay())
 	src := make(signal.Discrete, 32)
@@ -47,8 +68,118 @@ func BenchmarkRingcopy(b *testing.B) {
 	}
 }
 
+func TestFreeze(t *testing.T) {
+	frz := NewFreeze(100*time.Millisecond, newtone(440))
+	frz.Prepare(1)
+	if frz.Progress() < 1 {
+		for _, x := range frz.Samples() {
+			if x != 0 {
+				t.Fatalf("have %v before ready, want 0", x)
+			}
+		}
+	}
+	frz.Wait()
+	if p := frz.Progress(); p != 1 {
+		t.Fatalf("have progress %v, want 1", p)
+	}
+	sig := frz.Signal()
+	if len(sig) != Dtof(100*time.Millisecond, frz.SampleRate()) {
+		t.Fatalf("have length %v", len(sig))
+	}
+	for i, x := range sig {
+		if want := math.Sin(float64(i) * Hertz(440).Normalized(frz.SampleRate())); !equals(x, want) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+
+	// rendering continues from state of input
+	frz.Refreeze()
+	frz.Wait()
+	if x := frz.Signal()[0]; equals(x, sig[0]) {
+		t.Fatalf("refreeze have %v, want continuation of input", x)
+	}
+}
+
+func TestFreezeRefreeze(t *testing.T) {
+	for _, live := range []bool{false, true} {
+		var frz *Freeze
+		if live {
+			frz = NewFreezeLive(10*time.Millisecond, newtone(440))
+		} else {
+			frz = NewFreeze(time.Second, newtone(440))
+		}
+		// superseded renderings are closed
+		done := frz.Done()
+		frz.Refreeze()
+		frz.Refreeze()
+		select {
+		case <-done:
+		default:
+			t.Fatalf("live=%v superseded rendering not done", live)
+		}
+		if live {
+			for tc := 1; frz.Progress() < 1; tc++ {
+				frz.in.Prepare(uint64(tc))
+				frz.Prepare(uint64(tc))
+			}
+		}
+		frz.Wait()
+		if p := frz.Progress(); p != 1 {
+			t.Fatalf("live=%v have progress %v, want 1", live, p)
+		}
+		if n := len(frz.Signal()); n != frz.n {
+			t.Fatalf("live=%v have length %v, want %v", live, n, frz.n)
+		}
+	}
+}
+
+func TestFreezeConcurrency(t *testing.T) {
+	defer SetFreezeConcurrency(runtime.NumCPU())
+	// concurrency set while rendering
+	frz := NewFreeze(100*time.Millisecond, newtone(440))
+	set := make(chan struct{})
+	go func() {
+		for n := 1; n <= 4; n++ {
+			SetFreezeConcurrency(n)
+		}
+		close(set)
+	}()
+	for n := 0; n < 4; n++ {
+		frz.Refreeze()
+	}
+	frz.Wait()
+	<-set
+	if p := frz.Progress(); p != 1 {
+		t.Fatalf("have progress %v, want 1", p)
+	}
+}
+
+func TestFreezeLive(t *testing.T) {
+	frz := NewFreezeLive(10*time.Millisecond, newtone(440))
+	n := Dtof(10*time.Millisecond, frz.SampleRate())
+	inps := GetInputs(frz)
+	var out []float64
+	for tc := 1; tc <= 8; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+		out = append(out, frz.Samples()...)
+	}
+	select {
+	case <-frz.Done():
+	default:
+		t.Fatal("capture not done")
+	}
+	for i, x := range out {
+		if want := math.Sin(float64(i%n) * Hertz(440).Normalized(frz.SampleRate())); !equals(x, want) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+}
+
 func BenchmarkFreeze(b *testing.B) {
 	frz := NewFreeze(1*time.Second, newunit())
+	frz.Wait()
 	b.ReportAllocs()
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
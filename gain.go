@@ -2,23 +2,29 @@ package snd
 
 type Gain struct {
 	*mono
-	a float64
+	a  float64
+	va float64
 }
 
 func NewGain(a float64, in Sound) *Gain {
-	return &Gain{newmono(in), a}
+	return &Gain{newmono(in), a, 1}
 }
 
 func (gn *Gain) SetAmp(a float64) {
 	gn.a = a
 }
 
+// SetVelocity scales amplitude by vel where rng is the attenuation at zero velocity.
+func (gn *Gain) SetVelocity(vel Velocity, rng Decibel) {
+	gn.va = vel.Amp(rng)
+}
+
 func (gn *Gain) Prepare(uint64) {
 	for i, x := range gn.in.Samples() {
 		if gn.off {
 			gn.out[i] = 0
 		} else {
-			gn.out[i] = gn.a * x
+			gn.out[i] = gn.a * gn.va * x
 		}
 	}
 }
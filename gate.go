@@ -0,0 +1,155 @@
+package snd
+
+import (
+	"math"
+	"time"
+)
+
+// Gate attenuates a signal by a range while its level is below a threshold,
+// acting as a noise gate or, with a finite ratio, a downward expander.
+//
+// Gate opens once level reaches threshold and closes once level falls below
+// threshold less hysteresis for longer than hold, so it does not chatter on
+// signals hovering about threshold. Level is detected from input or from a
+// sidechain if set, either of which may be filtered, such as a high-pass
+// keeping a kick drum from opening a gate on hi-hats.
+type Gate struct {
+	*stereo
+	side Sound
+
+	thr, hyst, rng float64 // decibels
+	ratio          float64 // infinite if gating
+
+	det  [2]detector
+	key  [2]biquad
+	filt bool
+
+	hold, held int // frames
+	open       bool
+	env        follower
+	sr         float64
+
+	gr *gainout
+}
+
+// NewGate returns Gate of in with threshold, 80dB range, 3dB hysteresis,
+// 10ms hold, 1ms attack and 100ms release.
+func NewGate(threshold Decibel, in Sound) *Gate {
+	gt := &Gate{stereo: newstereo(in), ratio: math.Inf(1), sr: in.SampleRate()}
+	gt.SetThreshold(threshold)
+	gt.SetRange(80)
+	gt.SetHysteresis(3)
+	gt.SetHold(10 * time.Millisecond)
+	gt.SetTimes(time.Millisecond, 100*time.Millisecond)
+	gt.gr = &gainout{newmono(gt)}
+	return gt
+}
+
+// NewExpander returns Gate of in with threshold and ratio, otherwise as NewGate.
+func NewExpander(threshold Decibel, ratio float64, in Sound) *Gate {
+	gt := NewGate(threshold, in)
+	gt.SetRatio(ratio)
+	return gt
+}
+
+// SetThreshold sets level at which gate opens.
+func (gt *Gate) SetThreshold(threshold Decibel) { gt.thr = float64(threshold) }
+
+// SetRange sets greatest attenuation of a closed gate.
+func (gt *Gate) SetRange(rng Decibel) { gt.rng = math.Max(float64(rng), 0) }
+
+// SetHysteresis sets amount below threshold level must fall for gate to close.
+func (gt *Gate) SetHysteresis(hyst Decibel) { gt.hyst = math.Max(float64(hyst), 0) }
+
+// SetHold sets time gate remains open after level falls below threshold less hysteresis.
+func (gt *Gate) SetHold(d time.Duration) { gt.hold = Dtof(d, gt.sr) }
+
+// SetRatio sets ratio of attenuation to level below threshold while closed,
+// at least 1. An infinite ratio gates, attenuating by full range.
+func (gt *Gate) SetRatio(ratio float64) { gt.ratio = math.Max(ratio, 1) }
+
+// SetTimes sets time for gate to open and to close.
+func (gt *Gate) SetTimes(attack, release time.Duration) {
+	// attenuation rises as gate closes
+	gt.env.set(release, attack, gt.sr)
+}
+
+// SetRMS sets detection to root mean square averaged over window, or peak if false.
+func (gt *Gate) SetRMS(rms bool, window time.Duration) {
+	for c := range gt.det {
+		gt.det[c].rms = rms
+		gt.det[c].a = math.Exp(-1 / (window.Seconds() * gt.sr))
+	}
+}
+
+// SetSidechain sets sound level is detected from, or input if nil.
+func (gt *Gate) SetSidechain(side Sound) { gt.side = side }
+
+// SetKeyFilter filters signal level is detected from by typ at hz with resonance q.
+func (gt *Gate) SetKeyFilter(typ BiquadType, hz, q float64) {
+	for c := range gt.key {
+		gt.key[c].set(typ, Hertz(clamp(hz, 1, 0.49*gt.sr)).Normalized(gt.sr), q, 0)
+	}
+	gt.filt = true
+}
+
+// ClearKeyFilter removes filter of signal level is detected from.
+func (gt *Gate) ClearKeyFilter() { gt.filt = false }
+
+// GainReduction returns gain applied as a Sound, suitable as a modulation source.
+func (gt *Gate) GainReduction() Sound { return gt.gr }
+
+// Open reports whether gate is open.
+func (gt *Gate) Open() bool { return gt.open }
+
+func (gt *Gate) Inputs() []Sound { return []Sound{gt.in, gt.side} }
+
+// attenuation returns attenuation in decibels for level lvl, updating state of gate.
+func (gt *Gate) attenuation(lvl float64) float64 {
+	switch {
+	case lvl >= gt.thr:
+		gt.open, gt.held = true, gt.hold
+	case lvl < gt.thr-gt.hyst:
+		if gt.held > 0 {
+			gt.held--
+		} else {
+			gt.open = false
+		}
+	}
+	if gt.open {
+		return 0
+	}
+	if math.IsInf(gt.ratio, 1) {
+		return gt.rng
+	}
+	return math.Min((gt.thr-lvl)*(gt.ratio-1), gt.rng)
+}
+
+func (gt *Gate) Prepare(uint64) {
+	for i := range gt.l.out {
+		l, r := frame(gt.in, i)
+		dl, dr := l, r
+		if gt.side != nil {
+			dl, dr = frame(gt.side, i)
+		}
+		if gt.filt {
+			dl, dr = gt.key[0].process(dl), gt.key[1].process(dr)
+		}
+
+		lvl := math.Max(gt.det[0].level(dl), gt.det[1].level(dr))
+		g := Decibel(-gt.env.next(gt.attenuation(lvl))).Amp()
+		gt.gr.out[i] = g
+		if gt.l.off {
+			gt.l.out[i] = 0
+		} else {
+			gt.l.out[i] = l * g
+		}
+		if gt.r.off {
+			gt.r.out[i] = 0
+		} else {
+			gt.r.out[i] = r * g
+		}
+		gt.out[i*2] = gt.l.out[i]
+		gt.out[i*2+1] = gt.r.out[i]
+	}
+}
@@ -0,0 +1,90 @@
+package snd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGate(t *testing.T) {
+	for _, td := range []struct {
+		amp, want float64
+	}{
+		{1, 1},
+		{0.01, 0.01 * Decibel(-80).Amp()}, // -40dB
+	} {
+		gt := NewGate(-20, NewGain(td.amp, newtone(440)))
+		if a := amplitude(gt, 200, 20); !equaleps(a, td.want, td.want/100) {
+			t.Errorf("amplitude %v have %v, want %v", td.amp, a, td.want)
+		}
+	}
+}
+
+func TestGateHysteresis(t *testing.T) {
+	gt := NewGate(-20, newunit())
+	gt.SetHold(0)
+	for _, td := range []struct {
+		lvl  float64
+		open bool
+	}{
+		{-30, false},
+		{-20, true},
+		{-22, true}, // within hysteresis
+		{-24, false},
+		{-22, false},
+	} {
+		gt.attenuation(td.lvl)
+		if gt.Open() != td.open {
+			t.Errorf("level %vdB have open %v, want %v", td.lvl, gt.Open(), td.open)
+		}
+	}
+
+	// held open after level falls
+	gt.hold = 10
+	gt.attenuation(-20)
+	for i := 0; i < 10; i++ {
+		if gt.attenuation(-40); !gt.Open() {
+			t.Fatalf("closed after %v frames, want hold of 10", i)
+		}
+	}
+	if gt.attenuation(-40); gt.Open() {
+		t.Fatal("open after hold")
+	}
+}
+
+func TestExpander(t *testing.T) {
+	// sine of amplitude -40dB has a root mean square of -43dB
+	gt := NewExpander(-23, 2, NewGain(0.01, newtone(440)))
+	gt.SetRMS(true, 10*time.Millisecond)
+	if a, want := amplitude(gt, 200, 20), 0.01*Decibel(-20).Amp(); !equaleps(a, want, want/20) {
+		t.Fatalf("have amplitude %v, want %v", a, want)
+	}
+}
+
+func TestGateKeyFilter(t *testing.T) {
+	for _, td := range []struct {
+		filt bool
+		want float64
+	}{
+		{false, 1},
+		{true, Decibel(-80).Amp()},
+	} {
+		// low tone above threshold is removed from detection by high-pass
+		gt := NewGate(-20, newtone(100))
+		if td.filt {
+			gt.SetKeyFilter(BiquadHighPass, 2000, 0.7)
+		}
+		if a := amplitude(gt, 200, 20); !equaleps(a, td.want, td.want/100) {
+			t.Errorf("filter %v have amplitude %v, want %v", td.filt, a, td.want)
+		}
+	}
+}
+
+func BenchmarkGate(b *testing.B) {
+	gt := NewGate(-20, newunit())
+	gt.SetKeyFilter(BiquadHighPass, 100, 0.7)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		gt.Prepare(uint64(n))
+	}
+}
@@ -0,0 +1,64 @@
+package snd
+
+import "math"
+
+// Ladder is a 4-pole lowpass filter modeled after the Moog transistor ladder.
+//
+// The ladder is solved without a unit delay in its feedback path so tuning and
+// resonance hold across the frequency range. Resonance belongs to [0..1] and
+// values beyond 1 self-oscillate. Drive amplifies input into the nonlinear
+// input stage for saturation. Frequency and resonance may be modulated at audio rate.
+type Ladder struct {
+	*mono
+	freq, res param
+	drive     float64
+
+	// integrator states
+	s [4]float64
+}
+
+func NewLadder(freq, res float64, in Sound) *Ladder {
+	sr := in.SampleRate()
+	return &Ladder{mono: newmono(in), freq: newparam(freq, nil, sr), res: newparam(res, nil, sr), drive: 1}
+}
+
+// SetFreq sets cutoff frequency in hertz, multiplied by mod if not nil.
+func (f *Ladder) SetFreq(hz float64, mod Sound) { f.freq.set(hz, mod) }
+
+// SetRes sets resonance, multiplied by mod if not nil.
+func (f *Ladder) SetRes(res float64, mod Sound) { f.res.set(res, mod) }
+
+// SetDrive sets input gain into the ladder stages.
+func (f *Ladder) SetDrive(drive float64) { f.drive = drive }
+
+func (f *Ladder) Inputs() []Sound { return []Sound{f.in, f.freq.mod, f.res.mod} }
+
+func (f *Ladder) Prepare(uint64) {
+	sr := f.in.SampleRate()
+	for i, x := range f.in.Samples() {
+		g := math.Tan(math.Pi * clamp(f.freq.next(i), 1, 0.49*sr) / sr)
+		G := g / (1 + g)
+		k := 4 * math.Max(f.res.next(i), 0)
+
+		// estimate output from current states to resolve feedback
+		var S float64
+		for _, s := range f.s {
+			S = G*S + (1-G)*s
+		}
+		G4 := G * G * G * G
+		y := (G4*f.drive*x + S) / (1 + k*G4)
+
+		y = math.Tanh(f.drive*x - k*y)
+		for j, s := range f.s {
+			v := G * (y - s)
+			y = v + s
+			f.s[j] = y + v
+		}
+
+		if f.off {
+			f.out[i] = 0
+		} else {
+			f.out[i] = y
+		}
+	}
+}
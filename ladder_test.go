@@ -0,0 +1,29 @@
+package snd
+
+import "testing"
+
+func TestLadder(t *testing.T) {
+	if x := amplitude(NewLadder(1000, 0, newtone(100)), 64, 64); x < 0.7 {
+		t.Errorf("passband attenuated to %v", x)
+	}
+	if x := amplitude(NewLadder(1000, 0, newtone(8000)), 64, 64); x > 0.01 {
+		t.Errorf("stopband passed at %v", x)
+	}
+
+	gn := NewGain(1, newtone(440))
+	f := NewLadder(1000, 1.1, gn)
+	amplitude(f, 0, 1)
+	gn.SetAmp(0)
+	if x := amplitude(f, 64, 64); x < 0.1 {
+		t.Errorf("did not self-oscillate, have %v", x)
+	}
+}
+
+func BenchmarkLadder(b *testing.B) {
+	f := NewLadder(1000, 0.5, newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		f.Prepare(uint64(n))
+	}
+}
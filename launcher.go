@@ -0,0 +1,70 @@
+package snd
+
+// Launcher mixes loops whose state changes are quantized to a shared transport.
+//
+// Loops appended to a launcher are clocked by its transport; playback of each
+// is kept in phase with the frame its first pass began.
+type Launcher struct {
+	*mono
+	tp    *Transport
+	q     Quantize
+	loops []*Loop
+	fn    func(*Loop, LoopState)
+}
+
+// NewLauncher returns Launcher quantizing state changes of loops to beats of tp.
+func NewLauncher(tp *Transport, loops ...*Loop) *Launcher {
+	ln := &Launcher{mono: newmono(nil), tp: tp, q: QuantizeBeat}
+	for _, lp := range loops {
+		ln.Append(lp)
+	}
+	return ln
+}
+
+// Append adds lp to launcher, clocked by its transport.
+func (ln *Launcher) Append(lp *Loop) {
+	lp.tp = ln.tp
+	if ln.fn != nil {
+		lp.OnState(ln.fn)
+	}
+	ln.loops = append(ln.loops, lp)
+}
+
+// SetQuantize sets boundary state changes are delayed to.
+func (ln *Launcher) SetQuantize(q Quantize) { ln.q = q }
+
+// OnState sets fn to be called with each change of state of all loops.
+// It is called during Prepare and must not block.
+func (ln *Launcher) OnState(fn func(*Loop, LoopState)) {
+	ln.fn = fn
+	for _, lp := range ln.loops {
+		lp.OnState(fn)
+	}
+}
+
+// Launch queues change of lp to state at next boundary of transport.
+//
+// Recording begins a new first pass; playing ends a recording or overdub,
+// or resumes playback in phase; stopping silences playback.
+func (ln *Launcher) Launch(lp *Loop, state LoopState) {
+	lp.queue(state, ln.tp.Next(ln.q))
+}
+
+func (ln *Launcher) Inputs() []Sound {
+	inps := make([]Sound, len(ln.loops))
+	for i, lp := range ln.loops {
+		inps[i] = lp
+	}
+	return inps
+}
+
+func (ln *Launcher) Prepare(uint64) {
+	for i := range ln.out {
+		ln.out[i] = 0
+		if !ln.off {
+			for _, lp := range ln.loops {
+				ln.out[i] += lp.Index(i)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package snd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLauncher(t *testing.T) {
+	tp := NewTransport(120, 4)
+	beat := int(DefaultSampleRate / 2)
+	lp := NewLoop(4*time.Second, newtone(440))
+	lp.SetCrossfade(0)
+	ln := NewLauncher(tp, lp)
+
+	var states []LoopState
+	ln.OnState(func(_ *Loop, state LoopState) { states = append(states, state) })
+
+	inps := GetInputs(ln)
+	tc := uint64(0)
+	until := func(f uint64) {
+		for tp.next <= f {
+			tc++
+			for _, inp := range inps {
+				inp.sd.Prepare(tc)
+			}
+		}
+	}
+
+	until(1000)
+	ln.Launch(lp, LoopRecording)
+	if !lp.Syncing() {
+		t.Fatal("launch not queued")
+	}
+	until(uint64(beat) + 1000)
+	ln.Launch(lp, LoopPlaying)
+	until(uint64(2*beat) + 1000)
+	if n := lp.Frames(); n != beat {
+		t.Fatalf("have %v frames, want %v", n, beat)
+	}
+
+	ln.SetQuantize(QuantizeBar)
+	ln.Launch(lp, LoopStopped)
+	until(uint64(4*beat) + 1000)
+	if x := ln.Index(0); x != 0 {
+		t.Fatalf("stopped loop have %v, want 0", x)
+	}
+	ln.Launch(lp, LoopPlaying)
+	until(uint64(8*beat) + 1000)
+
+	// playback in phase with first pass that began at first beat
+	buf := lp.layers[lp.layer]
+	for i, x := range ln.Samples() {
+		f := tp.Frame() + uint64(i) - uint64(beat)
+		if want := buf[f%uint64(beat)]; !equals(x, want) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+
+	want := []LoopState{LoopRecording, LoopPlaying, LoopStopped, LoopPlaying}
+	if len(states) != len(want) {
+		t.Fatalf("have states %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("have states %v, want %v", states, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package snd
+
+import (
+	"math"
+	"math/rand"
+
+	"dasa.cc/signal"
+)
+
+// LFOShape is the waveform produced by an LFO.
+type LFOShape int
+
+const (
+	LFOSine LFOShape = iota
+	LFOTriangle
+	LFOSawtooth
+	LFOSquare
+	LFORandom       // sample-and-hold, a new random value each cycle
+	LFOSmoothRandom // random values joined by cosine interpolation
+)
+
+// LFO is a low frequency oscillator intended as a modulation source.
+//
+// Output is bipolar [-1..1] by default, or unipolar [0..1], and is then
+// scaled by depth and shifted by offset. Rate is either free-running in
+// hertz or synced to a note division of a BPM.
+type LFO struct {
+	*mono
+	shape LFOShape
+	sig   signal.Discrete
+
+	freq  float64
+	phase float64
+
+	depth, offset float64
+	unipolar      bool
+	retrig        bool
+
+	// random values at start and end of current cycle
+	prv, nxt float64
+}
+
+// NewLFO returns a bipolar LFO of shape at freq hertz with depth of 1.
+func NewLFO(shape LFOShape, freq float64) *LFO {
+	lfo := &LFO{
+		mono:  newmono(nil),
+		freq:  freq,
+		depth: 1,
+		nxt:   rand.Float64()*2 - 1,
+	}
+	lfo.SetShape(shape)
+	return lfo
+}
+
+// SetShape sets waveform of lfo.
+func (lfo *LFO) SetShape(shape LFOShape) {
+	lfo.shape = shape
+	switch shape {
+	case LFOSine:
+		lfo.sig = signal.Sine()
+	case LFOTriangle:
+		lfo.sig = signal.Triangle()
+	case LFOSawtooth:
+		lfo.sig = signal.Sawtooth()
+	case LFOSquare:
+		lfo.sig = signal.Square()
+	default:
+		lfo.sig = nil
+	}
+}
+
+// SetFreq sets free-running rate of lfo in hertz.
+func (lfo *LFO) SetFreq(hz float64) { lfo.freq = hz }
+
+// SetSync sets rate of lfo to one cycle per note division div at bpm.
+func (lfo *LFO) SetSync(bpm BPM, div Division) {
+	lfo.freq = 1 / bpm.Div(div).Seconds()
+}
+
+// Freq returns rate of lfo in hertz.
+func (lfo *LFO) Freq() float64 { return lfo.freq }
+
+// SetDepth sets output of lfo as offset + depth*x.
+func (lfo *LFO) SetDepth(depth, offset float64) {
+	lfo.depth = depth
+	lfo.offset = offset
+}
+
+// SetUnipolar sets range of lfo to [0..1] before depth and offset are applied.
+func (lfo *LFO) SetUnipolar(b bool) { lfo.unipolar = b }
+
+// SetRetrigger sets whether On restarts lfo from phase zero, such as on note-on.
+func (lfo *LFO) SetRetrigger(b bool) { lfo.retrig = b }
+
+// Trigger restarts lfo from phase zero.
+func (lfo *LFO) Trigger() {
+	lfo.phase = 0
+	lfo.prv, lfo.nxt = lfo.nxt, rand.Float64()*2-1
+}
+
+func (lfo *LFO) On() {
+	if lfo.retrig {
+		lfo.Trigger()
+	}
+	lfo.mono.On()
+}
+
+func (lfo *LFO) Prepare(uint64) {
+	nfreq := lfo.freq / lfo.sr
+	for i := range lfo.out {
+		if lfo.off {
+			lfo.out[i] = 0
+		} else {
+			var x float64
+			switch lfo.shape {
+			case LFORandom:
+				x = lfo.nxt
+			case LFOSmoothRandom:
+				x = lfo.prv + (lfo.nxt-lfo.prv)*(1-math.Cos(math.Pi*lfo.phase))/2
+			default:
+				x = lfo.sig.At(lfo.phase)
+			}
+			if lfo.unipolar {
+				x = (x + 1) / 2
+			}
+			lfo.out[i] = lfo.offset + lfo.depth*x
+		}
+
+		lfo.phase += nfreq
+		if lfo.phase >= 1 {
+			lfo.phase -= 1
+			lfo.prv, lfo.nxt = lfo.nxt, rand.Float64()*2-1
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package snd
+
+import "testing"
+
+func TestLFOSync(t *testing.T) {
+	lfo := NewLFO(LFOSine, 1)
+	lfo.SetSync(120, Quarter)
+	if !equals(lfo.Freq(), 2) {
+		t.Fatalf("have %v, want 2Hz", lfo.Freq())
+	}
+}
+
+func TestLFORange(t *testing.T) {
+	for _, shape := range []LFOShape{LFOSine, LFORandom, LFOSmoothRandom} {
+		lfo := NewLFO(shape, 400)
+		lfo.SetUnipolar(true)
+		lfo.SetDepth(0.5, 0.25)
+		for n := 1; n <= 16; n++ {
+			lfo.Prepare(uint64(n))
+			for i, x := range lfo.Samples() {
+				if x < 0.25-epsilon || x > 0.75+epsilon {
+					t.Fatalf("shape(%v) sample %v out of range: %v", shape, i, x)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkLFO(b *testing.B) {
+	lfo := NewLFO(LFOSmoothRandom, 4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		lfo.Prepare(uint64(n))
+	}
+}
@@ -0,0 +1,181 @@
+package snd
+
+import (
+	"math"
+	"time"
+)
+
+// truepeak estimates peaks between frames by 4x oversampling with a polyphase FIR.
+type truepeak struct {
+	phases [4][]float64
+	hist   []float64 // recent input, newest at w-1
+	w      int
+	grid   int // frames ago of input aligned with interpolated points
+}
+
+func newtruepeak(sr float64) *truepeak {
+	h := LowPassFIR(47, 0.45*sr, 4*sr, KaiserWindow(8))
+	tp := &truepeak{hist: make([]float64, (len(h)+3)/4), grid: (len(h) - 1) / 8}
+	for i, c := range h {
+		tp.phases[i%4] = append(tp.phases[i%4], 4*c)
+	}
+	return tp
+}
+
+// delay returns group delay of detection in frames, rounded up.
+func (tp *truepeak) delay() int { return (len(tp.hist)*4 + 6) / 8 }
+
+// next returns greatest magnitude of interpolated points preceding x.
+func (tp *truepeak) next(x float64) float64 {
+	tp.hist[tp.w] = x
+	if tp.w++; tp.w == len(tp.hist) {
+		tp.w = 0
+	}
+	// frames themselves are included as the filter attenuates near nyquist
+	j := tp.w - 1 - tp.grid
+	if j < 0 {
+		j += len(tp.hist)
+	}
+	peak := math.Abs(tp.hist[j])
+	for _, ph := range tp.phases {
+		var y float64
+		j := tp.w
+		for _, c := range ph {
+			if j--; j < 0 {
+				j = len(tp.hist) - 1
+			}
+			y += c * tp.hist[j]
+		}
+		peak = math.Max(peak, math.Abs(y))
+	}
+	return peak
+}
+
+// Limiter is a lookahead brickwall limiter keeping a stereo signal below a
+// ceiling in decibels true peak.
+//
+// Peaks are detected at four times the sample rate so inter-sample peaks
+// produced on conversion are also held below the ceiling. Gain is lowered
+// over the lookahead time before a peak arrives, delaying output by Latency.
+// Limiter is suited as the last sound before a Player.
+type Limiter struct {
+	*stereo
+	ceil float64 // linear
+	rel  float64 // release coefficient
+	look int     // lookahead in frames
+
+	tp    [2]*truepeak
+	lines [2]*bufc
+
+	// minimum required gain held over lookahead then averaged over lookahead,
+	// each one frame longer so frames either side of a peak are also lowered
+	hold, box []float64
+	hw, bw    int
+	sum, g    float64
+	gainred   *gainout
+}
+
+// NewLimiter returns Limiter of in with ceiling of -1dBTP, 2ms lookahead and 100ms release.
+func NewLimiter(in Sound) *Limiter {
+	lim := &Limiter{stereo: newstereo(in), g: 1}
+	sr := in.SampleRate()
+	for c := range lim.tp {
+		lim.tp[c] = newtruepeak(sr)
+	}
+	lim.SetCeiling(-1)
+	lim.SetRelease(100 * time.Millisecond)
+	lim.SetLookahead(2 * time.Millisecond)
+	lim.gainred = &gainout{newmono(lim)}
+	return lim
+}
+
+// SetCeiling sets greatest true peak level of output.
+func (lim *Limiter) SetCeiling(db Decibel) { lim.ceil = db.Amp() }
+
+// SetRelease sets time for gain to recover after a peak.
+func (lim *Limiter) SetRelease(d time.Duration) {
+	lim.rel = math.Exp(-1 / (d.Seconds() * lim.SampleRate()))
+}
+
+// SetLookahead sets time gain is lowered over before a peak, and so latency of output.
+func (lim *Limiter) SetLookahead(d time.Duration) {
+	n := Dtof(d, lim.SampleRate())
+	if n < 1 {
+		n = 1
+	}
+	lim.look = n
+	lim.hold = make([]float64, n+2)
+	lim.box = make([]float64, n+1)
+	for i := range lim.hold {
+		lim.hold[i] = 1
+	}
+	for i := range lim.box {
+		lim.box[i] = 1
+	}
+	lim.hw, lim.bw, lim.sum = 0, 0, float64(len(lim.box))
+	for c := range lim.lines {
+		lim.lines[c] = newbufc(lim.latency(), 0)
+	}
+}
+
+// latency returns delay of output in frames.
+func (lim *Limiter) latency() int { return lim.look + lim.tp[0].delay() }
+
+// Latency returns delay of output relative to input.
+func (lim *Limiter) Latency() time.Duration { return Ftod(lim.latency(), lim.SampleRate()) }
+
+// GainReduction returns gain applied as a Sound, suitable as a modulation source.
+func (lim *Limiter) GainReduction() Sound { return lim.gainred }
+
+func (lim *Limiter) Prepare(uint64) {
+	for i := range lim.l.out {
+		l, r := frame(lim.in, i)
+		peak := math.Max(lim.tp[0].next(l), lim.tp[1].next(r))
+		req := 1.0
+		if peak > lim.ceil {
+			req = lim.ceil / peak
+		}
+
+		lim.hold[lim.hw] = req
+		if lim.hw++; lim.hw == len(lim.hold) {
+			lim.hw = 0
+		}
+		held := req
+		for _, x := range lim.hold {
+			held = math.Min(held, x)
+		}
+		lim.sum += held - lim.box[lim.bw]
+		lim.box[lim.bw] = held
+		if lim.bw++; lim.bw == len(lim.box) {
+			lim.bw = 0
+			// recalculate to avoid accumulating rounding error
+			lim.sum = 0
+			for _, x := range lim.box {
+				lim.sum += x
+			}
+		}
+		g := lim.sum / float64(len(lim.box))
+		if g < lim.g {
+			lim.g = g
+		} else {
+			lim.g = g + lim.rel*(lim.g-g)
+		}
+		lim.gainred.out[i] = lim.g
+
+		dl, dr := lim.lines[0].read(), lim.lines[1].read()
+		lim.lines[0].write(l)
+		lim.lines[1].write(r)
+		if lim.l.off {
+			lim.l.out[i] = 0
+		} else {
+			lim.l.out[i] = dl * lim.g
+		}
+		if lim.r.off {
+			lim.r.out[i] = 0
+		} else {
+			lim.r.out[i] = dr * lim.g
+		}
+		lim.out[i*2] = lim.l.out[i]
+		lim.out[i*2+1] = lim.r.out[i]
+	}
+}
@@ -0,0 +1,69 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// quarter is a sine at a quarter of the sample rate whose frames miss its peaks.
+type quarter struct {
+	*mono
+	a float64
+	n int
+}
+
+func (q *quarter) Prepare(uint64) {
+	for i := range q.out {
+		q.out[i] = q.a * math.Sin(math.Pi/2*float64(q.n)+math.Pi/4)
+		q.n++
+	}
+}
+
+func (q *quarter) Inputs() []Sound { return nil }
+
+func TestLimiterCeiling(t *testing.T) {
+	ceil := Decibel(-1).Amp()
+	for _, in := range []Sound{NewGain(2, newtone(1000)), NewGain(4, newimpulse())} {
+		lim := NewLimiter(in)
+		l, r := frames(lim, 20)
+		for i := range l {
+			if math.Abs(l[i]) > ceil+1e-9 || math.Abs(r[i]) > ceil+1e-9 {
+				t.Fatalf("%T have %v exceeding ceiling %v [i=%v]", in, l[i], ceil, i)
+			}
+		}
+	}
+}
+
+func TestLimiterTruePeak(t *testing.T) {
+	// frames of unit sine have magnitude 0.707 below ceiling but true peak of 1
+	lim := NewLimiter(&quarter{mono: newmono(nil), a: 1})
+	prepare(lim, 1, 20)
+	if g := lim.GainReduction().Samples()[0]; !equaleps(g, Decibel(-1).Amp(), 0.02) {
+		t.Fatalf("have gain %v, want %v", g, Decibel(-1).Amp())
+	}
+}
+
+func TestLimiterLatency(t *testing.T) {
+	lim := NewLimiter(NewGain(0.5, newimpulse()))
+	lim.SetLookahead(time.Millisecond)
+	n := lim.latency()
+	if d := Ftod(n, lim.SampleRate()); lim.Latency() != d {
+		t.Fatalf("have latency %s, want %s", lim.Latency(), d)
+	}
+	l, _ := frames(lim, 1)
+	for i, x := range l {
+		if i == n && !equals(x, 0.5) || i != n && !equals(x, 0) {
+			t.Fatalf("have %v at frame %v, want impulse at frame %v", x, i, n)
+		}
+	}
+}
+
+func BenchmarkLimiter(b *testing.B) {
+	lim := NewLimiter(newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		lim.Prepare(uint64(n))
+	}
+}
@@ -0,0 +1,360 @@
+package snd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultLoopCrossfade is the time over which a Loop blends its end into its start.
+const DefaultLoopCrossfade = 10 * time.Millisecond
+
+// LoopState is the activity of a Loop.
+type LoopState int
+
+const (
+	LoopEmpty LoopState = iota
+	LoopRecording
+	LoopPlaying
+	LoopOverdubbing
+	LoopStopped
+)
+
+func (s LoopState) String() string {
+	switch s {
+	case LoopEmpty:
+		return "empty"
+	case LoopRecording:
+		return "recording"
+	case LoopPlaying:
+		return "playing"
+	case LoopOverdubbing:
+		return "overdubbing"
+	case LoopStopped:
+		return "stopped"
+	}
+	return fmt.Sprintf("LoopState(%d)", int(s))
+}
+
+// Loop records a signal, repeating the recording in subsequent playback.
+//
+// Length of the loop is set by the first pass, from Record to Stop, limited to
+// the duration given at construction. Input continuing after the first pass is
+// crossfaded into the start of the loop so its boundary does not click.
+//
+// Overdubbing records onto a copy of the current layer; layers may then be
+// undone and redone. Playback speed may be changed, or reversed if negative.
+//
+// State changes may be queued to a frame of a Transport, see Launcher.
+type Loop struct {
+	*mono
+	layers [][]float64
+	layer  int // index of current layer
+	cap    int // greatest length in frames
+	n      int // length in frames, zero until first pass completes
+	w      int // write position of first pass
+
+	pos, speed float64
+	fb         float64 // amplitude of existing layer while overdubbing
+
+	// crossfade length and frames of first pass remaining to blend
+	xfade, tail int
+	// cell of current layer last given feedback while overdubbing
+	dubcell int
+
+	state LoopState
+	fn    func(*Loop, LoopState)
+
+	// clock of frames from transport, or buffer count if nil
+	tp *Transport
+	f  uint64
+	// frame first pass began, for phase of playback
+	origin uint64
+
+	// queued state change
+	queued bool
+	next   LoopState
+	at     uint64
+	sync   int
+}
+
+// NewLoop returns a Loop with sample buffer of a length approximated by d.
+func NewLoop(d time.Duration, in Sound) *Loop {
+	return NewLoopFrames(Dtof(d, in.SampleRate()), in)
+}
+
+// NewLoopFrames return a Loop with sample buffer of length nframes.
+func NewLoopFrames(nframes int, in Sound) *Loop {
+	lp := &Loop{mono: newmono(in), cap: nframes, speed: 1, fb: 1}
+	lp.SetCrossfade(DefaultLoopCrossfade)
+	return lp
+}
+
+// SetBPM delays Record until next beat at bpm and rounds length of first
+// pass to whole beats.
+func (lp *Loop) SetBPM(bpm BPM) {
+	lp.sync = Dtof(bpm.Dur(), lp.SampleRate())
+}
+
+// SetCrossfade sets time over which end of first pass is blended into start of loop.
+func (lp *Loop) SetCrossfade(d time.Duration) { lp.xfade = Dtof(d, lp.SampleRate()) }
+
+// SetSpeed sets rate of playback where 1 is original speed, 0.5 is half and
+// 2 is double. Negative speeds play in reverse.
+func (lp *Loop) SetSpeed(speed float64) { lp.speed = speed }
+
+// SetFeedback sets amplitude of existing layer while overdubbing where fb belongs to [0..1].
+func (lp *Loop) SetFeedback(fb float64) { lp.fb = clamp(fb, 0, 1) }
+
+// OnState sets fn to be called with each change of state. It is called
+// during Prepare and must not block.
+func (lp *Loop) OnState(fn func(*Loop, LoopState)) { lp.fn = fn }
+
+func (lp *Loop) State() LoopState { return lp.state }
+
+// Syncing reports whether a change of state is queued.
+func (lp *Loop) Syncing() bool { return lp.queued }
+
+// Recording reports whether first pass or an overdub is being recorded.
+func (lp *Loop) Recording() bool {
+	return lp.state == LoopRecording || lp.state == LoopOverdubbing
+}
+
+func (lp *Loop) Overdubbing() bool { return lp.state == LoopOverdubbing }
+
+// Frames returns length of loop in frames, or zero if first pass is incomplete.
+func (lp *Loop) Frames() int { return lp.n }
+
+func (lp *Loop) Inputs() []Sound {
+	if lp.tp == nil {
+		return []Sound{lp.in}
+	}
+	return []Sound{lp.in, lp.tp}
+}
+
+// Record clears all layers and begins first pass, on next beat if SetBPM was called.
+func (lp *Loop) Record() {
+	if lp.sync == 0 {
+		lp.set(LoopRecording)
+	} else {
+		lp.queue(LoopRecording, (lp.f+uint64(lp.sync)-1)/uint64(lp.sync)*uint64(lp.sync))
+	}
+}
+
+// Overdub records input onto a new layer copied from the current one,
+// discarding any layers previously undone. Record is called instead if
+// first pass is incomplete.
+func (lp *Loop) Overdub() {
+	if lp.n == 0 {
+		lp.Record()
+		return
+	}
+	lp.set(LoopOverdubbing)
+}
+
+// Stop ends recording and overdubbing, continuing playback. If stopping
+// first pass, its length is set and rounded to whole beats if synced.
+func (lp *Loop) Stop() {
+	lp.queued = false
+	if lp.state == LoopRecording || lp.state == LoopOverdubbing {
+		lp.set(LoopPlaying)
+	}
+}
+
+// Halt ends recording and overdubbing and silences playback. Playback
+// resumes in phase with the first pass when played again.
+func (lp *Loop) Halt() {
+	lp.queued = false
+	lp.set(LoopStopped)
+}
+
+// Play ends recording and overdubbing or resumes playback if halted.
+func (lp *Loop) Play() {
+	lp.queued = false
+	lp.set(LoopPlaying)
+}
+
+// queue changes state of lp when clock reaches frame at.
+func (lp *Loop) queue(state LoopState, at uint64) {
+	lp.queued, lp.next, lp.at = true, state, at
+}
+
+// set changes state of lp immediately.
+func (lp *Loop) set(state LoopState) {
+	switch state {
+	case LoopRecording:
+		lp.layers = [][]float64{make([]float64, lp.cap)}
+		lp.layer, lp.n, lp.w, lp.pos, lp.tail = 0, 0, 0, 0, 0
+		lp.origin = lp.f
+	case LoopOverdubbing:
+		if lp.state == LoopRecording {
+			lp.finish()
+		}
+		if lp.n == 0 || lp.state == LoopOverdubbing {
+			return
+		}
+		if lp.state == LoopStopped {
+			lp.align()
+		}
+		buf := make([]float64, lp.n)
+		copy(buf, lp.layers[lp.layer])
+		lp.layers = append(lp.layers[:lp.layer+1], buf)
+		lp.layer++
+		lp.dubcell = -1
+	case LoopPlaying, LoopStopped:
+		if lp.state == LoopRecording {
+			lp.finish()
+		}
+		if lp.n == 0 {
+			state = LoopEmpty
+		} else if lp.state == LoopStopped {
+			lp.align()
+		}
+	}
+	if state == lp.state {
+		return
+	}
+	lp.state = state
+	if lp.fn != nil {
+		lp.fn(lp, state)
+	}
+}
+
+// finish ends first pass, setting its length.
+func (lp *Loop) finish() {
+	n := lp.w
+	if lp.sync != 0 {
+		beats := (n + lp.sync/2) / lp.sync
+		if beats < 1 {
+			beats = 1
+		}
+		n = beats * lp.sync
+		if n > lp.cap {
+			n = lp.cap - lp.cap%lp.sync
+		}
+	}
+	lp.n = n
+	lp.pos = 0
+	lp.tail = lp.xfade
+	if lp.tail > n {
+		lp.tail = n
+	}
+}
+
+// align sets playback position to where it would be had the loop played at
+// its current speed since the frame first pass began.
+func (lp *Loop) align() {
+	n := float64(lp.n)
+	lp.pos = math.Mod(float64(lp.f-lp.origin)*lp.speed, n)
+	if lp.pos < 0 {
+		lp.pos += n
+	}
+	if lp.pos >= n {
+		lp.pos = 0
+	}
+	lp.tail = 0
+}
+
+// Undo reverts to previous layer, reporting whether there was one.
+func (lp *Loop) Undo() bool {
+	if lp.state == LoopOverdubbing {
+		lp.set(LoopPlaying)
+	}
+	if lp.layer == 0 {
+		return false
+	}
+	lp.layer--
+	return true
+}
+
+// Redo restores next layer previously undone, reporting whether there was one.
+func (lp *Loop) Redo() bool {
+	if lp.state == LoopOverdubbing || lp.layer+1 >= len(lp.layers) {
+		return false
+	}
+	lp.layer++
+	return true
+}
+
+// dub adds x to cells of buf passed by pos in a frame, in proportion to the
+// portion of each passed, so overdubs are continuous at any speed. Existing
+// contents of a cell are scaled by feedback once as it is entered.
+func (lp *Loop) dub(buf []float64, x float64) {
+	lo, hi := lp.pos, lp.pos+lp.speed
+	if hi < lo {
+		lo, hi = hi, lo
+	}
+	for c := math.Floor(lo); c < hi; c++ {
+		k := int(c) % lp.n
+		if k < 0 {
+			k += lp.n
+		}
+		if k != lp.dubcell {
+			buf[k] *= lp.fb
+			lp.dubcell = k
+		}
+		buf[k] += x * (math.Min(hi, c+1) - math.Max(lo, c))
+	}
+}
+
+// read returns current layer at pos by linear interpolation.
+func (lp *Loop) read(buf []float64) float64 {
+	i := int(lp.pos)
+	t := lp.pos - float64(i)
+	j := i + 1
+	if j == lp.n {
+		j = 0
+	}
+	return buf[i] + t*(buf[j]-buf[i])
+}
+
+func (lp *Loop) Prepare(tc uint64) {
+	if lp.tp != nil {
+		lp.f = lp.tp.Frame()
+	} else {
+		lp.f = tc * uint64(len(lp.out))
+	}
+	for i := range lp.out {
+		if lp.queued && lp.f >= lp.at {
+			lp.queued = false
+			lp.set(lp.next)
+		}
+		lp.f++
+
+		lp.out[i] = 0
+		x := lp.in.Index(i)
+		switch lp.state {
+		case LoopEmpty:
+			continue
+		case LoopRecording:
+			lp.layers[0][lp.w] = x
+			if lp.w++; lp.w == lp.cap {
+				lp.set(LoopPlaying)
+			}
+			continue
+		}
+
+		buf := lp.layers[lp.layer]
+		if lp.tail > 0 {
+			// blend continuation of first pass into start of loop at the
+			// rate it was recorded
+			k := lp.xfade - lp.tail
+			t := float64(k) / float64(lp.xfade)
+			buf[k] = x*(1-t) + buf[k]*t
+			lp.tail--
+		} else if lp.state == LoopOverdubbing {
+			lp.dub(buf, x)
+		}
+		if lp.state != LoopStopped && !lp.off {
+			lp.out[i] = lp.read(buf)
+		}
+
+		lp.pos += lp.speed
+		for lp.pos >= float64(lp.n) {
+			lp.pos -= float64(lp.n)
+		}
+		for lp.pos < 0 {
+			lp.pos += float64(lp.n)
+		}
+	}
+}
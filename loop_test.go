@@ -0,0 +1,163 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// prepare prepares all inputs of sd for buffers from through to inclusive.
+func prepare(sd Sound, from, to int) {
+	inps := GetInputs(sd)
+	for tc := from; tc <= to; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+	}
+}
+
+func TestLoopFirstPass(t *testing.T) {
+	tn := newtone(441)
+	lp := NewLoop(time.Second, tn)
+	lp.SetCrossfade(0)
+	lp.Record()
+	prepare(lp, 1, 3)
+	lp.Stop()
+	if n := lp.Frames(); n != 3*DefaultBufferLen {
+		t.Fatalf("have %v frames, want %v", n, 3*DefaultBufferLen)
+	}
+	// playback repeats first pass
+	prepare(lp, 4, 4)
+	for i, x := range lp.Samples() {
+		if want := math.Sin(float64(i) * Hertz(441).Normalized(lp.SampleRate())); !equals(x, want) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+}
+
+func TestLoopOverdubUndo(t *testing.T) {
+	lp := NewLoop(time.Second, newzeros())
+	lp.SetCrossfade(0)
+	lp.Record()
+	prepare(lp, 1, 2)
+	lp.Stop()
+
+	lp.Overdub()
+	prepare(lp, 3, 4)
+	lp.Stop()
+	check := func(want float64) {
+		prepare(lp, 5, 5)
+		for _, x := range lp.Samples() {
+			if !equals(x, want) {
+				t.Fatalf("have %v, want %v", x, want)
+			}
+		}
+	}
+	check(2)
+	if !lp.Undo() {
+		t.Fatal("undo failed")
+	}
+	check(1)
+	if lp.Undo() {
+		t.Fatal("undo past first layer")
+	}
+	if !lp.Redo() {
+		t.Fatal("redo failed")
+	}
+	check(2)
+
+	// recording again clears all layers
+	lp.Record()
+	if lp.Undo() || lp.Redo() || lp.Frames() != 0 {
+		t.Fatal("record did not clear layers")
+	}
+}
+
+func TestLoopOverdubSpeed(t *testing.T) {
+	for _, speed := range []float64{0.5, 2, -2, 0.25} {
+		lp := NewLoop(time.Second, newunit())
+		lp.SetCrossfade(0)
+		lp.Record()
+		prepare(lp, 1, 2)
+		lp.Stop()
+
+		// a single pass at speed writes each cell once with feedback applied once
+		lp.SetFeedback(0.5)
+		lp.SetSpeed(speed)
+		lp.Overdub()
+		n := int(2 / math.Abs(speed))
+		prepare(lp, 3, 2+n)
+		for i, x := range lp.layers[lp.layer] {
+			if !equaleps(x, 1.5*DefaultAmpFac, 1e-9) {
+				t.Fatalf("speed %v have %v, want %v [i=%v]", speed, x, 1.5*DefaultAmpFac, i)
+			}
+		}
+	}
+}
+
+func TestLoopAlign(t *testing.T) {
+	lp := NewLoopFrames(100, newzeros())
+	lp.n, lp.origin, lp.f = 100, 20, 150
+	for _, td := range []struct{ speed, want float64 }{
+		{1, 30},
+		{0.5, 65},
+		{2, 60},
+		{-1, 70},
+	} {
+		lp.SetSpeed(td.speed)
+		if lp.align(); lp.pos != td.want {
+			t.Errorf("speed %v have position %v, want %v", td.speed, lp.pos, td.want)
+		}
+	}
+}
+
+func TestLoopReverse(t *testing.T) {
+	lp := NewLoopFrames(4, newtone(1000))
+	lp.SetCrossfade(0)
+	lp.Record()
+	prepare(lp, 1, 1) // fills and stops at capacity
+	if n := lp.Frames(); n != 4 {
+		t.Fatalf("have %v frames, want 4", n)
+	}
+	lp.SetSpeed(-1)
+	prepare(lp, 2, 2)
+	buf := lp.layers[0]
+	for i, x := range lp.Samples() {
+		if want := buf[(4-i%4)%4]; !equals(x, want) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+}
+
+func TestLoopCrossfade(t *testing.T) {
+	// loop length not a whole number of cycles is discontinuous at its boundary
+	var jump [2]float64
+	for i, xf := range []time.Duration{0, DefaultLoopCrossfade} {
+		tn := newtone(300)
+		lp := NewLoopFrames(1000, tn)
+		lp.SetCrossfade(xf)
+		lp.Record()
+		prepare(lp, 1, 8)
+		buf := lp.layers[lp.layer]
+		jump[i] = math.Abs(buf[0] - buf[lp.n-1])
+	}
+	if jump[1] >= jump[0]/4 {
+		t.Fatalf("crossfaded boundary jump %v not less than %v", jump[1], jump[0])
+	}
+}
+
+func BenchmarkLoop(b *testing.B) {
+	lp := NewLoop(time.Second, newunit())
+	lp.Record()
+	for n := 1; n <= 10; n++ {
+		lp.Prepare(uint64(n))
+	}
+	lp.Stop()
+	lp.Overdub()
+	lp.SetSpeed(0.5)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		lp.Prepare(uint64(n))
+	}
+}
@@ -1,9 +1,10 @@
 package snd
 
-// TODO should mixer be stereo out?
+// Mixer sums mono inputs. See Console for a stereo mixer with gain, pan,
+// mute and solo of each input.
+//
 // TODO perhaps this class is unnecessary, any sound could be a mixer
 // if you can set multiple inputs, but might get confusing.
-// TODO consider embedding Gain type
 type Mixer struct {
 	*mono
 	ins []Sound
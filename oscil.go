@@ -1,6 +1,8 @@
 package snd
 
 import (
+	"math"
+
 	"dasa.cc/signal"
 )
 
@@ -17,6 +19,10 @@ type Oscil struct {
 	ampmod   Sound
 	freqmod  Sound
 	phasemod Sound
+
+	warp    Warp
+	warpamt float64
+	warpmod Sound
 }
 
 func NewOscil(in signal.Discrete, freq float64, freqmod Sound) *Oscil {
@@ -43,8 +49,16 @@ func (osc *Oscil) SetPhase(mod Sound) {
 	osc.phasemod = mod
 }
 
+// SetWarp sets phase distortion of osc where amt belongs to [0..1) and is
+// multiplied by mod if not nil. A nil fn disables phase distortion.
+func (osc *Oscil) SetWarp(fn Warp, amt float64, mod Sound) {
+	osc.warp = fn
+	osc.warpamt = amt
+	osc.warpmod = mod
+}
+
 func (osc *Oscil) Inputs() []Sound {
-	return []Sound{osc.freqmod, osc.ampmod, osc.phasemod}
+	return []Sound{osc.freqmod, osc.ampmod, osc.phasemod, osc.warpmod}
 }
 
 func (osc *Oscil) Prepare(tc uint64) {
@@ -69,7 +83,51 @@ func (osc *Oscil) Prepare(tc uint64) {
 			amp *= osc.ampmod.Index(frame + i)
 		}
 
-		osc.out[i] = amp * osc.in.At(osc.phase+offset)
+		t := osc.phase + offset
+		if osc.warp != nil {
+			amt := osc.warpamt
+			if osc.warpmod != nil {
+				amt *= osc.warpmod.Index(frame + i)
+			}
+			t = osc.warp(t-math.Floor(t), clamp(amt, 0, maxwarp))
+		}
+
+		osc.out[i] = amp * osc.in.At(t)
 		osc.phase += interval
 	}
 }
+
+// Warp distorts phase t belonging to [0..1) by amt belonging to [0..1) before
+// table lookup in the style of Casio CZ phase distortion synthesis. An amt of
+// zero must return t unaltered.
+type Warp func(t, amt float64) float64
+
+// maxwarp is the greatest amount given a Warp, short of 1 where the
+// transitions of WarpSaw and WarpSquare become instantaneous.
+const maxwarp = 1 - 1e-6
+
+// WarpSaw bends phase so the first half of a cycle is traversed faster than the
+// second; a sine table approaches a sawtooth as amt approaches 1.
+func WarpSaw(t, amt float64) float64 {
+	m := (1 - clamp(amt, 0, maxwarp)) / 2
+	if t < m {
+		return t * 0.5 / m
+	}
+	return 0.5 + (t-m)*0.5/(1-m)
+}
+
+// WarpSquare rushes phase to the peak of each half cycle, holds, and rushes
+// back; a sine table approaches a square as amt approaches 1.
+func WarpSquare(t, amt float64) float64 {
+	h := math.Floor(t*2) / 2
+	x := (t - h) * 2
+	r := (1 - clamp(amt, 0, maxwarp)) / 2
+	switch {
+	case x < r:
+		return h + x*0.25/r
+	case x < 1-r:
+		return h + 0.25
+	default:
+		return h + 0.25 + (x-1+r)*0.25/r
+	}
+}
@@ -1,6 +1,7 @@
 package snd
 
 import (
+	"math"
 	"testing"
 
 	"dasa.cc/signal"
@@ -81,3 +82,36 @@ func BenchmarkOscilReuse(b *testing.B) {
 		}
 	}
 }
+
+func TestWarp(t *testing.T) {
+	for _, fn := range []Warp{WarpSaw, WarpSquare} {
+		for i := 0; i < 64; i++ {
+			x := float64(i) / 64
+			if y := fn(x, 0); !equals(x, y) {
+				t.Fatalf("warp(%v, 0) have %v, want %v", x, y, x)
+			}
+			// amounts outside [0..1) are clamped
+			for _, amt := range []float64{0.25, 0.5, 0.9, 1, 1.5, -0.5} {
+				if y := fn(x, amt); math.IsNaN(y) || y < 0 || y >= 1 || (i > 0 && y < fn(float64(i-1)/64, amt)) {
+					t.Fatalf("warp(%v, %v) not monotonic in [0..1): %v", x, amt, y)
+				}
+			}
+		}
+	}
+	if y := WarpSquare(0.25, 0.5); !equals(y, 0.25) {
+		t.Fatalf("WarpSquare did not hold peak, have %v", y)
+	}
+}
+
+func BenchmarkOscilWarp(b *testing.B) {
+	osc := NewOscil(signal.Sine(), 440, nil)
+	osc.SetWarp(WarpSaw, 0.5, NewOscil(signal.Sine(), 2, nil))
+	inps := GetInputs(osc)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(n))
+		}
+	}
+}
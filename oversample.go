@@ -0,0 +1,177 @@
+package snd
+
+import "time"
+
+// oversampler converts a signal to and from a multiple of its sample rate
+// with polyphase FIR filters rejecting images and aliases above the original
+// nyquist frequency.
+type oversampler struct {
+	n int
+	h []float64
+
+	// history of input and of oversampled output, written twice to avoid wrapping
+	uphist, dnhist []float64
+	uw, dw         int
+}
+
+// newoversampler returns oversampler by factor n rounded up to a power of two, at least 2.
+func newoversampler(n int) *oversampler {
+	p := 2
+	for p < n && p < DefaultBufferLen {
+		p *= 2
+	}
+	// a cutoff below nyquist leaves room for the transition band
+	h := LowPassFIR(16*p+1, 0.45, float64(p), KaiserWindow(8))
+	return &oversampler{
+		n:      p,
+		h:      h,
+		uphist: make([]float64, 2*((len(h)+p-1)/p)),
+		dnhist: make([]float64, 2*(len(h)+p-1)),
+	}
+}
+
+// latency returns delay of a signal upsampled then downsampled, in frames at original rate.
+func (o *oversampler) latency() int { return (len(o.h) - 1) / o.n }
+
+// up writes n frames interpolated from x to dst.
+func (o *oversampler) up(x float64, dst []float64) {
+	m := len(o.uphist) / 2
+	if o.uw--; o.uw < 0 {
+		o.uw = m - 1
+	}
+	o.uphist[o.uw], o.uphist[o.uw+m] = x, x
+	for p := range dst[:o.n] {
+		var y float64
+		for k, j := p, o.uw; k < len(o.h); k, j = k+o.n, j+1 {
+			y += o.h[k] * o.uphist[j]
+		}
+		dst[p] = y * float64(o.n)
+	}
+}
+
+// down returns a frame decimated from n frames of src, aligned with the first.
+func (o *oversampler) down(src []float64) float64 {
+	m := len(o.dnhist) / 2
+	for _, x := range src[:o.n] {
+		if o.dw--; o.dw < 0 {
+			o.dw = m - 1
+		}
+		o.dnhist[o.dw], o.dnhist[o.dw+m] = x, x
+	}
+	var y float64
+	for k, h := range o.h {
+		y += h * o.dnhist[o.dw+o.n-1+k]
+	}
+	return y
+}
+
+// overfeed is mono input of sounds within an Oversample and is prepared by it.
+type overfeed struct{ *mono }
+
+func (fd *overfeed) Prepare(uint64) {}
+
+// stereofeed is stereo input of sounds within an Oversample and is prepared by it.
+type stereofeed struct{ *stereo }
+
+func (fd *stereofeed) Prepare(uint64) {}
+
+// Oversample prepares sounds built upon its input at a multiple of the sample
+// rate, reducing aliasing of nonlinear sounds such as Shaper and Distortion.
+//
+// Sounds built take the multiplied sample rate from the input given them and
+// must only depend on it. Input given them has as many channels as in. Output
+// is stereo, a mono sound built being output on both channels, and is delayed
+// by Latency.
+type Oversample struct {
+	*stereo
+	n    int
+	ovs  [2]*oversampler
+	feed Sound
+	root Sound
+	inps []*Input
+	tmp  []float64    // frames upsampled from a single input frame
+	buf  [2][]float64 // output of root by channel across all n passes
+}
+
+// NewOversample returns Oversample of in by factor n rounded up to a power of
+// two, preparing the sound returned by build.
+func NewOversample(n int, in Sound, build func(Sound) Sound) *Oversample {
+	ov := &Oversample{stereo: newstereo(in)}
+	for c := range ov.ovs {
+		ov.ovs[c] = newoversampler(n)
+	}
+	ov.n = ov.ovs[0].n
+	sr := in.SampleRate() * float64(ov.n)
+	if in.Channels() == 2 {
+		fd := &stereofeed{newstereo(nil)}
+		fd.l.sr, fd.r.sr = sr, sr
+		ov.feed = fd
+	} else {
+		fd := &overfeed{newmono(nil)}
+		fd.sr = sr
+		ov.feed = fd
+	}
+	ov.root = build(ov.feed)
+	ov.inps = GetInputs(ov.root)
+	ov.tmp = make([]float64, ov.n)
+	for c := range ov.buf {
+		ov.buf[c] = make([]float64, ov.n*len(ov.l.out))
+	}
+	return ov
+}
+
+// Latency returns delay of output relative to input.
+func (ov *Oversample) Latency() time.Duration { return Ftod(ov.ovs[0].latency(), ov.SampleRate()) }
+
+func (ov *Oversample) Prepare(tc uint64) {
+	if ov.l.off && ov.r.off {
+		for i := range ov.out {
+			ov.out[i] = 0
+		}
+		for i := range ov.l.out {
+			ov.l.out[i], ov.r.out[i] = 0, 0
+		}
+		return
+	}
+	in, ic := ov.in.Samples(), ov.in.Channels()
+	fd := ov.feed.Samples()
+	// each pass of sounds built prepares a buffer of the feed
+	frames := len(fd) / ic
+	span := frames / ov.n
+	for k := 0; k < ov.n; k++ {
+		for i := 0; i < span; i++ {
+			for c := 0; c < ic; c++ {
+				ov.ovs[c].up(in[(k*span+i)*ic+c], ov.tmp)
+				for p, x := range ov.tmp {
+					fd[(i*ov.n+p)*ic+c] = x
+				}
+			}
+		}
+		for _, inp := range ov.inps {
+			inp.sd.Prepare((tc-1)*uint64(ov.n) + uint64(k) + 1)
+		}
+		rs, rc := ov.root.Samples(), ov.root.Channels()
+		for c := 0; c < rc; c++ {
+			buf := ov.buf[c][k*frames:]
+			for j := range buf[:frames] {
+				buf[j] = rs[j*rc+c]
+			}
+		}
+	}
+	rc := ov.root.Channels()
+	for i := range ov.l.out {
+		l := ov.ovs[0].down(ov.buf[0][i*ov.n:])
+		r := l
+		if rc == 2 {
+			r = ov.ovs[1].down(ov.buf[1][i*ov.n:])
+		}
+		if ov.l.off {
+			l = 0
+		}
+		if ov.r.off {
+			r = 0
+		}
+		ov.l.out[i], ov.r.out[i] = l, r
+		ov.out[i*2], ov.out[i*2+1] = l, r
+	}
+}
@@ -0,0 +1,110 @@
+package snd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOversampler(t *testing.T) {
+	for _, n := range []int{2, 4, 8} {
+		// passband passes unchanged delayed by latency
+		ovs := newoversampler(n)
+		buf := make([]float64, ovs.n)
+		w := Hertz(1000).Normalized(DefaultSampleRate)
+		d := ovs.latency()
+		for i := 0; i < 1000; i++ {
+			ovs.up(math.Sin(float64(i)*w), buf)
+			y := ovs.down(buf)
+			if want := math.Sin(float64(i-d) * w); i > 2*d && !equaleps(y, want, 0.001) {
+				t.Fatalf("n=%v have %v, want %v [i=%v]", n, y, want, i)
+			}
+		}
+	}
+}
+
+func TestOversample(t *testing.T) {
+	var dst *Distortion
+	ov := NewOversample(3, newtone(1000), func(in Sound) Sound {
+		dst = NewDistortion(DistortionSoft, 1, in)
+		return NewGain(0.5, in)
+	})
+	// sounds built derive coefficients from the multiplied rate
+	if want := 4 * DefaultSampleRate; dst.SampleRate() != want {
+		t.Fatalf("have sample rate %v, want %v", dst.SampleRate(), want)
+	}
+	if want := newparam(1, nil, 4*DefaultSampleRate).a; dst.drive.a != want {
+		t.Fatalf("have smoothing coefficient %v, want %v", dst.drive.a, want)
+	}
+	if a := amplitude(ov, 4, 20); !equaleps(a, 0.5, 0.001) {
+		t.Fatalf("have amplitude %v, want 0.5", a)
+	}
+}
+
+func TestOversampleStereo(t *testing.T) {
+	tds := []struct {
+		in    Sound
+		build func(Sound) Sound
+		l, r  float64
+	}{
+		// stereo input passed through
+		{NewPan(-1, newtone(1000)), func(in Sound) Sound { return in }, 1, 0},
+		// stereo sound built upon mono input
+		{newtone(1000), func(in Sound) Sound { return NewPan(1, in) }, 0, 1},
+	}
+	for i, td := range tds {
+		ov := NewOversample(4, td.in, td.build)
+		inps := GetInputs(ov)
+		var l, r []float64
+		for tc := 1; tc <= 24; tc++ {
+			for _, inp := range inps {
+				inp.sd.Prepare(uint64(tc))
+			}
+			if tc > 4 {
+				for j := range ov.l.out {
+					l = append(l, ov.Samples()[j*2])
+					r = append(r, ov.Samples()[j*2+1])
+				}
+			}
+		}
+		if a := goertzel(l, 1000, ov.SampleRate()); !equaleps(a, td.l, 0.01) {
+			t.Errorf("tds[%v] have left amplitude %v, want %v", i, a, td.l)
+		}
+		if a := goertzel(r, 1000, ov.SampleRate()); !equaleps(a, td.r, 0.01) {
+			t.Errorf("tds[%v] have right amplitude %v, want %v", i, a, td.r)
+		}
+	}
+}
+
+func TestOversampleAlias(t *testing.T) {
+	// ninth harmonic of a clipped 5kHz tone aliases to 3kHz
+	alias := func(sd Sound) float64 {
+		inps := GetInputs(sd)
+		var xs []float64
+		for tc := 1; tc <= 44; tc++ {
+			for _, inp := range inps {
+				inp.sd.Prepare(uint64(tc))
+			}
+			if tc > 4 {
+				xs = append(xs, sd.Samples()...)
+			}
+		}
+		return goertzel(xs, 3000, sd.SampleRate())
+	}
+	dist := func(in Sound) Sound { return NewDistortion(DistortionHard, 4, in) }
+	have := alias(NewOversample(4, newtone(5000), dist))
+	want := alias(dist(newtone(5000)))
+	if want < 0.01 || have > want/10 {
+		t.Fatalf("have alias %v oversampled, want less than a tenth of %v", have, want)
+	}
+}
+
+func BenchmarkOversample(b *testing.B) {
+	ov := NewOversample(4, newunit(), func(in Sound) Sound {
+		return NewDistortion(DistortionSoft, 2, in)
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ov.Prepare(uint64(n + 1))
+	}
+}
@@ -6,7 +6,7 @@ var (
 	onesqrt2 = 1 / math.Sqrt(2)
 
 	panres float64 = 512
-	panfac [1024]float64
+	panfac [1025]float64
 )
 
 func init() {
@@ -0,0 +1,80 @@
+package snd
+
+import (
+	"math"
+	"time"
+)
+
+// ParamSmoothing is the time constant used to smooth parameter changes that
+// would otherwise produce audible zipper noise.
+const ParamSmoothing = 5 * time.Millisecond
+
+// param is a value smoothed on change and scaled by an optional modulating Sound.
+type param struct {
+	x, y float64 // target and smoothed value
+	a    float64 // smoothing coefficient
+	mod  Sound
+}
+
+func newparam(x float64, mod Sound, sr float64) param {
+	return param{x: x, y: x, a: math.Exp(-1 / (ParamSmoothing.Seconds() * sr)), mod: mod}
+}
+
+// set changes target value and modulation of p.
+func (p *param) set(x float64, mod Sound) { p.x, p.mod = x, mod }
+
+// settled reports whether p is unmodulated and done smoothing.
+func (p *param) settled() bool { return p.mod == nil && p.y == p.x }
+
+// next advances smoothing by a frame and returns value of p at frame i.
+func (p *param) next(i int) float64 {
+	if p.y != p.x {
+		p.y = p.x + p.a*(p.y-p.x)
+		if math.Abs(p.y-p.x) <= 1e-9*math.Max(math.Abs(p.x), 1) {
+			p.y = p.x
+		}
+	}
+	if p.mod == nil {
+		return p.y
+	}
+	return p.y * p.mod.Index(i)
+}
+
+// clamp returns x limited to [lo..hi].
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
+
+// follower tracks the envelope of a signal with separate attack and release.
+type follower struct {
+	atk, rel float64 // smoothing coefficients
+	y        float64
+}
+
+func newfollower(attack, release time.Duration, sr float64) follower {
+	var f follower
+	f.set(attack, release, sr)
+	return f
+}
+
+// set changes attack and release times of f.
+func (f *follower) set(attack, release time.Duration, sr float64) {
+	coef := func(d time.Duration) float64 {
+		if d <= 0 {
+			return 0
+		}
+		return math.Exp(-1 / (d.Seconds() * sr))
+	}
+	f.atk, f.rel = coef(attack), coef(release)
+}
+
+// next returns envelope of f after x.
+func (f *follower) next(x float64) float64 {
+	x = math.Abs(x)
+	a := f.rel
+	if x > f.y {
+		a = f.atk
+	}
+	f.y = x + a*(f.y-x)
+	return f.y
+}
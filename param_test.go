@@ -0,0 +1,18 @@
+package snd
+
+import "testing"
+
+func TestParamSettle(t *testing.T) {
+	for _, x := range []float64{0, 0.5, 1000} {
+		p := newparam(1, nil, DefaultSampleRate)
+		p.set(x, nil)
+		// settles within fifty time constants of smoothing
+		n := 50 * Dtof(ParamSmoothing, DefaultSampleRate)
+		for i := 0; i < n && !p.settled(); i++ {
+			p.next(0)
+		}
+		if !p.settled() {
+			t.Errorf("target %v not settled, have %v", x, p.y)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package snd
+
+import (
+	"math"
+	"time"
+
+	"dasa.cc/signal"
+)
+
+// PitchShift transposes a signal without changing its duration by reading a
+// delay line with two heads sweeping at the transposed rate, each faded in and
+// out of a window so its jump back across the line is not heard.
+type PitchShift struct {
+	*mono
+	line  *bufc
+	n     float64 // window length in frames
+	ratio float64
+	phase float64
+
+	wet, dry float64
+}
+
+// NewPitchShift returns PitchShift transposing in by semitones with a 50ms window.
+func NewPitchShift(semitones float64, in Sound) *PitchShift {
+	ps := &PitchShift{mono: newmono(in), wet: 1}
+	ps.SetShift(semitones)
+	ps.SetWindow(50 * time.Millisecond)
+	return ps
+}
+
+// SetShift sets transposition in semitones.
+func (ps *PitchShift) SetShift(semitones float64) { ps.ratio = math.Exp2(semitones / 12) }
+
+// SetWindow sets length of grains; longer windows smear transients while
+// shorter windows roughen low frequencies.
+func (ps *PitchShift) SetWindow(d time.Duration) {
+	n := Dtof(d, ps.SampleRate())
+	if n < 4 {
+		n = 4
+	}
+	ps.n = float64(n)
+	ps.line = newbufc(n+2, 0)
+}
+
+// SetMix sets amplitude of transposed and original signal.
+func (ps *PitchShift) SetMix(wet, dry float64) { ps.wet, ps.dry = wet, dry }
+
+func (ps *PitchShift) Prepare(uint64) {
+	step := (1 - ps.ratio) / ps.n
+	for i := range ps.out {
+		x := ps.in.Index(i)
+		var y float64
+		for _, h := range [2]float64{0, 0.5} {
+			p := ps.phase + h
+			if p >= 1 {
+				p--
+			}
+			// squared sines of heads half a window apart sum to one
+			g := math.Sin(math.Pi * p)
+			y += g * g * ps.line.frac(1+p*ps.n)
+		}
+		ps.line.write(x)
+
+		ps.phase += step
+		ps.phase -= math.Floor(ps.phase)
+
+		if ps.off {
+			ps.out[i] = 0
+		} else {
+			ps.out[i] = x*ps.dry + y*ps.wet
+		}
+	}
+}
+
+// TimeStretch returns sig lengthened by ratio without changing its pitch, by
+// phase vocoder with identity phase locking. A ratio of 2 doubles length and
+// 0.5 halves it; stretching a loop from one BPM to another is a ratio of from/to.
+func TimeStretch(sig signal.Discrete, ratio float64) signal.Discrete {
+	const (
+		n  = 2048
+		hs = n / 4 // synthesis hop
+	)
+	out := make(signal.Discrete, int(math.Round(float64(len(sig))*ratio)))
+	if len(sig) == 0 || len(out) == 0 {
+		return out
+	}
+	ha := hs / ratio // analysis hop
+
+	win := make([]float64, n)
+	for i := range win {
+		win[i] = HannWindow(i, n+1)
+	}
+
+	plan := newfftplan(n)
+	x := make([]complex128, n)
+	mag := make([]float64, n/2+1)
+	ph := make([]float64, n/2+1)
+	prv := make([]float64, n/2+1) // analysis phases of previous frame
+	acc := make([]float64, n/2+1) // synthesis phases
+	syn := make([]float64, n/2+1)
+	var peaks []int
+	norm := make([]float64, len(out)+n)
+	buf := make([]float64, len(out)+n)
+
+	for j := 0; ; j++ {
+		pos := float64(j) * ha
+		w := j * hs
+		if w >= len(out) {
+			break
+		}
+		start := int(math.Round(pos)) - n/2
+		for i := range x {
+			k := start + i
+			var s float64
+			if k >= 0 && k < len(sig) {
+				s = sig[k]
+			}
+			x[i] = complex(s*win[i], 0)
+		}
+		plan.transform(x, false)
+
+		for k := range mag {
+			mag[k], ph[k] = math.Hypot(real(x[k]), imag(x[k])), math.Atan2(imag(x[k]), real(x[k]))
+		}
+		peaks = peaks[:0]
+		for k := 1; k < n/2; k++ {
+			if mag[k] > mag[k-1] && mag[k] >= mag[k+1] {
+				peaks = append(peaks, k)
+			}
+		}
+
+		if j == 0 || len(peaks) == 0 {
+			copy(syn, ph)
+		} else {
+			// deviation from expected phase advance gives true frequency of a
+			// peak; surrounding bins are locked to their nearest peak to keep
+			// partials coherent.
+			for _, p := range peaks {
+				omega := twopi * float64(p) / n
+				dev := ph[p] - prv[p] - omega*ha
+				dev -= twopi * math.Round(dev/twopi)
+				syn[p] = acc[p] + (omega+dev/ha)*hs
+			}
+			q := 0
+			for k := range syn {
+				for q+1 < len(peaks) && peaks[q+1]-k < k-peaks[q] {
+					q++
+				}
+				p := peaks[q]
+				syn[k] = syn[p] + ph[k] - ph[p]
+			}
+		}
+		copy(prv, ph)
+		copy(acc, syn)
+
+		for k := range mag {
+			sin, cos := math.Sincos(acc[k])
+			x[k] = complex(mag[k]*cos, mag[k]*sin)
+			if k > 0 && k < n/2 {
+				x[n-k] = complex(mag[k]*cos, -mag[k]*sin)
+			}
+		}
+		plan.transform(x, true)
+
+		for i := range x {
+			k := w - n/2 + i
+			if k < 0 || k >= len(out) {
+				continue
+			}
+			buf[k] += real(x[i]) * win[i]
+			norm[k] += win[i] * win[i]
+		}
+	}
+	for i := range out {
+		if norm[i] > 1e-6 {
+			out[i] = buf[i] / norm[i]
+		}
+	}
+	return out
+}
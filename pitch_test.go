@@ -0,0 +1,97 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"dasa.cc/signal"
+)
+
+// goertzel returns amplitude of frequency hz in xs sampled at sr.
+func goertzel(xs []float64, hz, sr float64) float64 {
+	w := twopi * hz / sr
+	c := 2 * math.Cos(w)
+	var s1, s2 float64
+	for _, x := range xs {
+		s1, s2 = x+c*s1-s2, s1
+	}
+	return 2 * math.Sqrt(s1*s1+s2*s2-c*s1*s2) / float64(len(xs))
+}
+
+func TestPitchShift(t *testing.T) {
+	for _, td := range []struct{ st, hz float64 }{{12, 880}, {-12, 220}, {7, 440 * math.Exp2(7./12)}} {
+		ps := NewPitchShift(td.st, newtone(440))
+		inps := GetInputs(ps)
+		var xs []float64
+		for tc := 1; tc <= 60; tc++ {
+			for _, inp := range inps {
+				inp.sd.Prepare(uint64(tc))
+			}
+			if tc > 20 {
+				xs = append(xs, ps.Samples()...)
+			}
+		}
+		sr := ps.SampleRate()
+		if a, b := goertzel(xs, td.hz, sr), goertzel(xs, 440, sr); a < 0.5 || b > 0.1 {
+			t.Errorf("shift %v have %vHz amplitude %v and 440Hz amplitude %v", td.st, td.hz, a, b)
+		}
+	}
+}
+
+func TestTimeStretch(t *testing.T) {
+	sr := DefaultSampleRate
+	sig := make(signal.Discrete, int(sr)/2)
+	for i := range sig {
+		sig[i] = math.Sin(twopi * 440 * float64(i) / sr)
+	}
+	for _, ratio := range []float64{2, 0.5, 1.5} {
+		out := TimeStretch(sig, ratio)
+		if want := int(math.Round(float64(len(sig)) * ratio)); len(out) != want {
+			t.Fatalf("ratio %v have length %v, want %v", ratio, len(out), want)
+		}
+		mid := out[len(out)/4 : 3*len(out)/4]
+		if a := goertzel(mid, 440, sr); !equaleps(a, 1, 0.05) {
+			t.Errorf("ratio %v have 440Hz amplitude %v, want 1", ratio, a)
+		}
+	}
+}
+
+func TestFreezeSignal(t *testing.T) {
+	sig := make(signal.Discrete, 300)
+	for i := range sig {
+		sig[i] = float64(i)
+	}
+	frz := NewFreezeSignal(sig)
+	if len(frz.Signal()) != len(sig) {
+		t.Fatal("signal not frozen")
+	}
+	frz.Prepare(1)
+	frz.Prepare(2)
+	for i, x := range frz.Samples() {
+		if want := float64((DefaultBufferLen + i) % len(sig)); x != want {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i)
+		}
+	}
+}
+
+func BenchmarkPitchShift(b *testing.B) {
+	ps := NewPitchShift(7, newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ps.Prepare(uint64(n))
+	}
+}
+
+func BenchmarkTimeStretch(b *testing.B) {
+	sig := make(signal.Discrete, Dtof(time.Second, DefaultSampleRate))
+	for i := range sig {
+		sig[i] = math.Sin(float64(i) / 10)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		TimeStretch(sig, 1.25)
+	}
+}
@@ -258,6 +258,7 @@ func TestPlotFreeze(t *testing.T) {
 	osc1 := NewOscil(Sine(), 440, NewOscil(Sawtooth(), 23, nil))
 	osc1.SetPhase(NewOscil(Square(), 231, nil))
 	frz := NewFreeze(50*time.Millisecond, osc1)
+	frz.Wait()
 	plt.add("Freeze", frz)
 
 	if err := plt.save("freeze.png"); err != nil {
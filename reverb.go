@@ -0,0 +1,257 @@
+package snd
+
+import (
+	"math"
+	"time"
+)
+
+// MaxReverbMod is the greatest depth reverb delay lines may be modulated by.
+const MaxReverbMod = 5 * time.Millisecond
+
+// revbase holds parameters and input handling common to algorithmic reverbs.
+type revbase struct {
+	*stereo
+	pre      *bufc
+	width    float64
+	wet, dry float64
+
+	// modulation rate in hertz, depth in frames, and phase
+	modrate, moddepth, modphase float64
+}
+
+func newrevbase(in Sound) revbase {
+	return revbase{stereo: newstereo(in), width: 1, wet: 1}
+}
+
+// SetWidth sets stereo separation of reverberation where width belongs to [0..1].
+func (rv *revbase) SetWidth(width float64) { rv.width = width }
+
+// SetMix sets amplitude of reverberated and original signal.
+func (rv *revbase) SetMix(wet, dry float64) { rv.wet, rv.dry = wet, dry }
+
+// SetPreDelay delays onset of reverberation by d.
+func (rv *revbase) SetPreDelay(d time.Duration) {
+	if n := Dtof(d, rv.SampleRate()); n > 0 {
+		rv.pre = newbufc(n, 0)
+	} else {
+		rv.pre = nil
+	}
+}
+
+// SetModulation varies delay line lengths at rate hertz by up to depth,
+// limited to MaxReverbMod, reducing metallic resonance.
+func (rv *revbase) SetModulation(rate float64, depth time.Duration) {
+	if depth > MaxReverbMod {
+		depth = MaxReverbMod
+	}
+	rv.modrate = rate
+	rv.moddepth = float64(Dtof(depth, rv.SampleRate()))
+}
+
+// input returns left, right and pre-delayed mono input at frame i.
+func (rv *revbase) input(i int) (l, r, x float64) {
+	in := rv.in.Samples()
+	if rv.in.Channels() == 2 {
+		l, r = in[i*2], in[i*2+1]
+	} else {
+		l, r = in[i], in[i]
+	}
+	x = (l + r) / 2
+	if rv.pre != nil {
+		y := rv.pre.read()
+		rv.pre.write(x)
+		x = y
+	}
+	return
+}
+
+// mod advances modulation phase returning its sine and cosine.
+func (rv *revbase) mod() (sin, cos float64) {
+	rv.modphase += Hertz(rv.modrate).Normalized(rv.SampleRate())
+	if rv.modphase >= twopi {
+		rv.modphase -= twopi
+	}
+	return math.Sincos(rv.modphase)
+}
+
+// output writes wet signals wl and wr mixed by width with dry signals l and r at frame i.
+func (rv *revbase) output(i int, wl, wr, l, r float64) {
+	wet1 := rv.wet * (rv.width/2 + 0.5)
+	wet2 := rv.wet * (1 - rv.width) / 2
+	if rv.l.off {
+		rv.l.out[i] = 0
+	} else {
+		rv.l.out[i] = wl*wet1 + wr*wet2 + l*rv.dry
+	}
+	if rv.r.off {
+		rv.r.out[i] = 0
+	} else {
+		rv.r.out[i] = wr*wet1 + wl*wet2 + r*rv.dry
+	}
+	rv.out[i*2] = rv.l.out[i]
+	rv.out[i*2+1] = rv.r.out[i]
+}
+
+// revline is a delay line with lowpass feedback read at a modulated length.
+type revline struct {
+	line *bufc
+	n    float64 // length in frames
+	lp   float64
+
+	// sine and cosine of modulation phase offset
+	sin, cos float64
+}
+
+func newrevline(n int, depth float64, offset float64) revline {
+	sin, cos := math.Sincos(offset)
+	return revline{line: newbufc(n+int(depth)+2, 0), n: float64(n), sin: sin, cos: cos}
+}
+
+// read returns lowpassed output of ln with length modulated by depth.
+func (ln *revline) read(damp, depth, sin, cos float64) float64 {
+	d := ln.n
+	if depth != 0 {
+		d += depth * (1 + sin*ln.cos + cos*ln.sin) / 2
+	}
+	y := ln.line.frac(d)
+	ln.lp = y*(1-damp) + ln.lp*damp
+	return ln.lp
+}
+
+// Freeverb is a stereo reverb of parallel lowpass-feedback combs followed by
+// series allpasses as described by Jezar at Dreampoint.
+type Freeverb struct {
+	revbase
+	combs [2][8]revline
+	aps   [2][4]*bufc
+
+	fb, damp float64
+}
+
+var (
+	freeverbCombs  = [8]int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	freeverbAPs    = [4]int{556, 441, 341, 225}
+	freeverbSpread = 23
+)
+
+func NewFreeverb(in Sound) *Freeverb {
+	rv := &Freeverb{revbase: newrevbase(in)}
+	sr := rv.SampleRate()
+	scale := func(n int) int { return int(float64(n) * sr / 44100) }
+	depth := float64(Dtof(MaxReverbMod, sr))
+	for c := range rv.combs {
+		spread := c * freeverbSpread
+		for i, n := range freeverbCombs {
+			rv.combs[c][i] = newrevline(scale(n+spread), depth, twopi*float64(i+4*c)/16)
+		}
+		for i, n := range freeverbAPs {
+			rv.aps[c][i] = newbufc(scale(n+spread), 0)
+		}
+	}
+	rv.SetRoomSize(0.5)
+	rv.SetDamp(0.5)
+	return rv
+}
+
+// SetRoomSize sets feedback of combs where size belongs to [0..1].
+func (rv *Freeverb) SetRoomSize(size float64) { rv.fb = size*0.28 + 0.7 }
+
+// SetDamp sets amount high frequencies are absorbed where damp belongs to [0..1].
+func (rv *Freeverb) SetDamp(damp float64) { rv.damp = damp * 0.4 }
+
+func (rv *Freeverb) Prepare(uint64) {
+	for i := range rv.l.out {
+		l, r, x := rv.input(i)
+		x *= 0.015
+		sin, cos := rv.mod()
+
+		var w [2]float64
+		for c := range rv.combs {
+			for j := range rv.combs[c] {
+				ln := &rv.combs[c][j]
+				y := ln.read(rv.damp, rv.moddepth, sin, cos)
+				ln.line.write(x + y*rv.fb)
+				w[c] += y
+			}
+			for _, ap := range rv.aps[c] {
+				wd := ap.read()
+				v := w[c] + 0.5*wd
+				w[c] = wd - 0.5*v
+				ap.write(v)
+			}
+		}
+		rv.output(i, 3*w[0], 3*w[1], l, r)
+	}
+}
+
+// FDN is a stereo reverb of eight delay lines mixed by a Householder feedback matrix.
+type FDN struct {
+	revbase
+	lines [8]revline
+	g     [8]float64 // feedback gain of each line for decay
+
+	size, damp float64
+	decay      time.Duration
+}
+
+var fdnLines = [8]int{1427, 1777, 1973, 2099, 2557, 2879, 3221, 3511}
+
+func NewFDN(in Sound) *FDN {
+	rv := &FDN{revbase: newrevbase(in), size: 1, decay: 2 * time.Second}
+	sr := rv.SampleRate()
+	depth := float64(Dtof(MaxReverbMod, sr))
+	for i, n := range fdnLines {
+		rv.lines[i] = newrevline(int(float64(n)*sr/48000), depth, twopi*float64(i)/8)
+	}
+	rv.SetDamp(0.5)
+	rv.update()
+	return rv
+}
+
+// SetRoomSize scales length of delay lines where size belongs to [0..1].
+func (rv *FDN) SetRoomSize(size float64) {
+	rv.size = clamp(size, 0, 1)
+	rv.update()
+}
+
+// SetDamp sets amount high frequencies are absorbed where damp belongs to [0..1].
+func (rv *FDN) SetDamp(damp float64) { rv.damp = damp * 0.4 }
+
+// SetDecay sets time for reverberation to decay by 60dB.
+func (rv *FDN) SetDecay(d time.Duration) {
+	rv.decay = d
+	rv.update()
+}
+
+func (rv *FDN) update() {
+	sr := rv.SampleRate()
+	for i, n := range fdnLines {
+		ln := &rv.lines[i]
+		ln.n = float64(n) * sr / 48000 * (0.25 + 0.75*rv.size)
+		rv.g[i] = math.Pow(10, -3*ln.n/(rv.decay.Seconds()*sr))
+	}
+}
+
+func (rv *FDN) Prepare(uint64) {
+	var y [8]float64
+	for i := range rv.l.out {
+		l, r, x := rv.input(i)
+		sin, cos := rv.mod()
+
+		var sum, wl, wr float64
+		for j := range rv.lines {
+			y[j] = rv.g[j] * rv.lines[j].read(rv.damp, rv.moddepth, sin, cos)
+			sum += y[j]
+			if j%2 == 0 {
+				wl += y[j]
+			} else {
+				wr += y[j]
+			}
+		}
+		sum *= 2. / 8
+		for j := range rv.lines {
+			rv.lines[j].line.write(x + y[j] - sum)
+		}
+		rv.output(i, wl/4, wr/4, l, r)
+	}
+}
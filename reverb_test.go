@@ -0,0 +1,95 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// energy returns sum of squares of sd prepared from buffer from through to inclusive.
+func energy(sd Sound, from, to int) float64 {
+	inps := GetInputs(sd)
+	var sum float64
+	for tc := 1; tc <= to; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+		if tc >= from {
+			for _, x := range sd.Samples() {
+				sum += x * x
+			}
+		}
+	}
+	return sum
+}
+
+type reverb interface {
+	Sound
+	SetModulation(float64, time.Duration)
+	SetPreDelay(time.Duration)
+}
+
+func TestReverbTail(t *testing.T) {
+	for _, rv := range []reverb{NewFreeverb(newimpulse()), NewFDN(newimpulse())} {
+		rv.SetModulation(0.5, time.Millisecond)
+		rv.SetPreDelay(10 * time.Millisecond)
+		inps := GetInputs(rv)
+		var l, r, diff float64
+		for tc := 1; tc <= 100; tc++ {
+			for _, inp := range inps {
+				inp.sd.Prepare(uint64(tc))
+			}
+			out := rv.Samples()
+			for i := 0; i < len(out); i += 2 {
+				l += out[i] * out[i]
+				r += out[i+1] * out[i+1]
+				diff += math.Abs(out[i] - out[i+1])
+			}
+		}
+		if math.IsNaN(l+r) || l == 0 || r == 0 || diff == 0 {
+			t.Errorf("%T has no stereo tail, energy left %v right %v", rv, l, r)
+		}
+	}
+}
+
+func TestFDNDecay(t *testing.T) {
+	sr := DefaultSampleRate
+	buf := func(d time.Duration) int { return Dtof(d, sr) / DefaultBufferLen }
+	for _, td := range []struct {
+		decay time.Duration
+		long  bool
+	}{{500 * time.Millisecond, false}, {10 * time.Second, true}} {
+		rv := NewFDN(newimpulse())
+		rv.SetDamp(0)
+		rv.SetDecay(td.decay)
+		e0 := energy(rv, buf(100*time.Millisecond), buf(200*time.Millisecond))
+		rv = NewFDN(newimpulse())
+		rv.SetDamp(0)
+		rv.SetDecay(td.decay)
+		e1 := energy(rv, buf(600*time.Millisecond), buf(700*time.Millisecond))
+		db := 10 * math.Log10(e1/e0)
+		if long := db > -20; long != td.long {
+			t.Errorf("decay of %s fell %.1fdB over 500ms", td.decay, db)
+		}
+	}
+}
+
+func BenchmarkFreeverb(b *testing.B) {
+	rv := NewFreeverb(newunit())
+	rv.SetModulation(0.5, time.Millisecond)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		rv.Prepare(uint64(n))
+	}
+}
+
+func BenchmarkFDN(b *testing.B) {
+	rv := NewFDN(newunit())
+	rv.SetModulation(0.5, time.Millisecond)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		rv.Prepare(uint64(n))
+	}
+}
@@ -0,0 +1,75 @@
+package snd
+
+import "dasa.cc/signal"
+
+// Transfer returns a transfer function for Shaper sampled from fn over the domain [-1..1].
+func Transfer(fn func(x float64) float64) signal.Discrete {
+	const n = 1024
+	sig := make(signal.Discrete, n+1)
+	for i := range sig {
+		sig[i] = fn(2*float64(i)/n - 1)
+	}
+	return sig
+}
+
+// Shaper is a waveshaper mapping input through a transfer function.
+//
+// The transfer function spans the domain [-1..1] across its length and input
+// multiplied by drive is clamped to this domain before lookup.
+type Shaper struct {
+	*mono
+	tf    signal.Discrete
+	drive float64
+
+	ovs *oversampler
+	buf []float64
+}
+
+func NewShaper(tf signal.Discrete, drive float64, in Sound) *Shaper {
+	return &Shaper{mono: newmono(in), tf: tf, drive: drive}
+}
+
+func (sh *Shaper) SetDrive(drive float64) { sh.drive = drive }
+
+// SetOversample sets number of times input is oversampled before shaping,
+// rounded up to a power of two, to reduce aliasing introduced by the transfer
+// function. Oversampling delays output, see Oversample.
+func (sh *Shaper) SetOversample(n int) {
+	if n <= 1 {
+		sh.ovs, sh.buf = nil, nil
+		return
+	}
+	sh.ovs = newoversampler(n)
+	sh.buf = make([]float64, sh.ovs.n)
+}
+
+// shape returns x mapped by transfer function with linear interpolation.
+func (sh *Shaper) shape(x float64) float64 {
+	t := (x*sh.drive + 1) / 2 * float64(len(sh.tf)-1)
+	if t <= 0 {
+		return sh.tf[0]
+	}
+	if t >= float64(len(sh.tf)-1) {
+		return sh.tf[len(sh.tf)-1]
+	}
+	i := int(t)
+	frac := t - float64(i)
+	return sh.tf[i] + frac*(sh.tf[i+1]-sh.tf[i])
+}
+
+func (sh *Shaper) Prepare(uint64) {
+	for i, x := range sh.in.Samples() {
+		switch {
+		case sh.off:
+			sh.out[i] = 0
+		case sh.ovs == nil:
+			sh.out[i] = sh.shape(x)
+		default:
+			sh.ovs.up(x, sh.buf)
+			for j, y := range sh.buf {
+				sh.buf[j] = sh.shape(y)
+			}
+			sh.out[i] = sh.ovs.down(sh.buf)
+		}
+	}
+}
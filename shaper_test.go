@@ -0,0 +1,61 @@
+package snd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShaper(t *testing.T) {
+	tests := []struct {
+		fn    func(float64) float64
+		drive float64
+		want  float64
+	}{
+		{func(x float64) float64 { return x }, 1, DefaultAmpFac},
+		{func(x float64) float64 { return -x }, 1, -DefaultAmpFac},
+		{math.Tanh, 2, math.Tanh(2 * DefaultAmpFac)},
+		{math.Tanh, 10, math.Tanh(1)}, // clamped
+	}
+	for i, test := range tests {
+		sh := NewShaper(Transfer(test.fn), test.drive, newunit())
+		sh.Prepare(1)
+		for _, x := range sh.Samples() {
+			if !equaleps(x, test.want, 0.001) {
+				t.Fatalf("tests[%v] have %v, want %v", i, x, test.want)
+			}
+		}
+	}
+}
+
+func TestShaperOversample(t *testing.T) {
+	sh := NewShaper(Transfer(math.Tanh), 1, newtone(1000))
+	sh.SetOversample(4)
+	d := sh.ovs.latency()
+	sh.in.Prepare(1)
+	sh.Prepare(1)
+	w := Hertz(1000).Normalized(sh.SampleRate())
+	for i, x := range sh.Samples()[2*d:] {
+		if want := math.Tanh(math.Sin(float64(i+d) * w)); !equaleps(x, want, 0.01) {
+			t.Fatalf("have %v, want %v [i=%v]", x, want, i+2*d)
+		}
+	}
+}
+
+func BenchmarkShaper(b *testing.B) {
+	sh := NewShaper(Transfer(math.Tanh), 2, newunit())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		sh.Prepare(uint64(n))
+	}
+}
+
+func BenchmarkShaperOversample(b *testing.B) {
+	sh := NewShaper(Transfer(math.Tanh), 2, newunit())
+	sh.SetOversample(4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		sh.Prepare(uint64(n))
+	}
+}
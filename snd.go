@@ -132,6 +132,29 @@ func (bpm BPM) Hertz() float64 {
 	return float64(bpm) / 2
 }
 
+// Div returns the time duration of note division div at bpm where a beat is a quarter note.
+func (bpm BPM) Div(div Division) time.Duration {
+	return time.Duration(float64(bpm.Dur()) * float64(div) * 4)
+}
+
+// Division is a note value relative to a whole note.
+type Division float64
+
+const (
+	Whole        Division = 1
+	Half         Division = 1. / 2
+	Quarter      Division = 1. / 4
+	Eighth       Division = 1. / 8
+	Sixteenth    Division = 1. / 16
+	ThirtySecond Division = 1. / 32
+)
+
+// Dotted returns div extended by half its value.
+func (div Division) Dotted() Division { return div * 3 / 2 }
+
+// Triplet returns div with three notes played in the time of two.
+func (div Division) Triplet() Division { return div * 2 / 3 }
+
 // TODO rename as Buffer?
 // TODO what about handling []byte instead of []float?
 // Sound represents a type capable of producing sound data.
@@ -178,9 +201,14 @@ type mono struct {
 	off bool
 }
 
+// newmono returns mono at sample rate of in, or DefaultSampleRate if nil.
 func newmono(in Sound) *mono {
+	sr := DefaultSampleRate
+	if in != nil {
+		sr = in.SampleRate()
+	}
 	return &mono{
-		sr:  DefaultSampleRate,
+		sr:  sr,
 		in:  in,
 		out: make(signal.Discrete, DefaultBufferLen),
 	}
@@ -204,13 +232,18 @@ type stereo struct {
 	tc   uint64
 }
 
+// newstereo returns stereo at sample rate of in, or DefaultSampleRate if nil.
 func newstereo(in Sound) *stereo {
-	return &stereo{
+	sd := &stereo{
 		l:   newmono(nil),
 		r:   newmono(nil),
 		in:  in,
 		out: make(signal.Discrete, DefaultBufferLen*2),
 	}
+	if in != nil {
+		sd.l.sr, sd.r.sr = in.SampleRate(), in.SampleRate()
+	}
+	return sd
 }
 
 func (sd *stereo) SampleRate() float64      { return sd.l.sr }
@@ -220,6 +253,6 @@ func (sd *stereo) At(t float64) float64     { return sd.out.At(t) }
 func (sd *stereo) Interp(t float64) float64 { return sd.out.Interp(t) }
 func (sd *stereo) Channels() int            { return 2 }
 func (sd *stereo) IsOff() bool              { return sd.l.off || sd.r.off }
-func (sd *stereo) Off()                     { sd.l.off, sd.r.off = false, false }
-func (sd *stereo) On()                      { sd.l.off, sd.r.off = true, true }
+func (sd *stereo) Off()                     { sd.l.off, sd.r.off = true, true }
+func (sd *stereo) On()                      { sd.l.off, sd.r.off = false, false }
 func (sd *stereo) Inputs() []Sound          { return []Sound{sd.in} }
@@ -1,6 +1,7 @@
 package snd
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -46,6 +47,59 @@ func (z *zeros) Prepare(uint64) {
 
 func (z *zeros) Inputs() []Sound { return nil }
 
+// impulse is a single unit sample at the start of the first buffer.
+type impulse struct{ *mono }
+
+func newimpulse() *impulse { return &impulse{newmono(nil)} }
+
+func (imp *impulse) Prepare(tc uint64) {
+	for i := range imp.out {
+		imp.out[i] = 0
+	}
+	if tc == 1 {
+		imp.out[0] = 1
+	}
+}
+
+func (imp *impulse) Inputs() []Sound { return nil }
+
+// tone is a sine of unit amplitude calculated without table lookup.
+type tone struct {
+	*mono
+	hz, phase float64
+}
+
+func newtone(hz float64) *tone { return &tone{mono: newmono(nil), hz: hz} }
+
+func (tn *tone) Prepare(uint64) {
+	for i := range tn.out {
+		tn.out[i] = math.Sin(tn.phase)
+		tn.phase += Hertz(tn.hz).Normalized(tn.sr)
+	}
+}
+
+func (tn *tone) Inputs() []Sound { return nil }
+
+// amplitude returns the amplitude of sd as a sinusoid calculated from its root
+// mean square over n buffers after first preparing skip buffers.
+func amplitude(sd Sound, skip, n int) float64 {
+	inps := GetInputs(sd)
+	var sum float64
+	var count int
+	for tc := 1; tc <= skip+n; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+		if tc > skip {
+			for _, x := range sd.Samples() {
+				sum += x * x
+				count++
+			}
+		}
+	}
+	return math.Sqrt(2 * sum / float64(count))
+}
+
 func BenchmarkZeros(b *testing.B) {
 	z := newzeros()
 	b.ReportAllocs()
@@ -156,3 +210,20 @@ func TestDecibel(t *testing.T) {
 		}
 	}
 }
+
+func TestBPMDiv(t *testing.T) {
+	tests := []struct {
+		div  Division
+		want time.Duration
+	}{
+		{Quarter, 500 * time.Millisecond},
+		{Whole, 2 * time.Second},
+		{Eighth.Dotted(), 375 * time.Millisecond},
+		{Quarter.Triplet(), 333333333},
+	}
+	for _, test := range tests {
+		if have := BPM(120).Div(test.div); have != test.want {
+			t.Errorf("%v have %s, want %s", test.div, have, test.want)
+		}
+	}
+}
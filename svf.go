@@ -0,0 +1,75 @@
+package snd
+
+import "math"
+
+// SVF is a topology-preserving state variable filter producing lowpass,
+// bandpass, highpass and notch responses simultaneously.
+//
+// SVF outputs lowpass; other responses are available as Sounds sharing the
+// same filter state. Frequency and Q may be modulated at audio rate.
+type SVF struct {
+	*mono
+	freq, q param
+
+	// integrator states
+	ic1, ic2 float64
+
+	bp, hp, notch *svfout
+}
+
+// svfout holds an alternate response of SVF and is prepared by it.
+type svfout struct{ *mono }
+
+func (out *svfout) Prepare(uint64) {}
+
+func NewSVF(freq, q float64, in Sound) *SVF {
+	sr := in.SampleRate()
+	f := &SVF{mono: newmono(in), freq: newparam(freq, nil, sr), q: newparam(q, nil, sr)}
+	f.bp = &svfout{newmono(f)}
+	f.hp = &svfout{newmono(f)}
+	f.notch = &svfout{newmono(f)}
+	return f
+}
+
+// SetFreq sets cutoff frequency in hertz, multiplied by mod if not nil.
+func (f *SVF) SetFreq(hz float64, mod Sound) { f.freq.set(hz, mod) }
+
+// SetQ sets resonance, multiplied by mod if not nil.
+func (f *SVF) SetQ(q float64, mod Sound) { f.q.set(q, mod) }
+
+// BandPass returns the bandpass response of f normalized to unity gain at cutoff.
+func (f *SVF) BandPass() Sound { return f.bp }
+
+// HighPass returns the highpass response of f.
+func (f *SVF) HighPass() Sound { return f.hp }
+
+// Notch returns the notch response of f.
+func (f *SVF) Notch() Sound { return f.notch }
+
+func (f *SVF) Inputs() []Sound { return []Sound{f.in, f.freq.mod, f.q.mod} }
+
+func (f *SVF) Prepare(uint64) {
+	sr := f.in.SampleRate()
+	for i, v0 := range f.in.Samples() {
+		g := math.Tan(math.Pi * clamp(f.freq.next(i), 1, 0.49*sr) / sr)
+		k := 1 / math.Max(f.q.next(i), 0.01)
+		a1 := 1 / (1 + g*(g+k))
+		a2 := g * a1
+		a3 := g * a2
+
+		v3 := v0 - f.ic2
+		v1 := a1*f.ic1 + a2*v3
+		v2 := f.ic2 + a2*f.ic1 + a3*v3
+		f.ic1 = 2*v1 - f.ic1
+		f.ic2 = 2*v2 - f.ic2
+
+		if f.off {
+			f.out[i], f.bp.out[i], f.hp.out[i], f.notch.out[i] = 0, 0, 0, 0
+		} else {
+			f.out[i] = v2
+			f.bp.out[i] = k * v1
+			f.hp.out[i] = v0 - k*v1 - v2
+			f.notch.out[i] = v0 - k*v1
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package snd
+
+import "testing"
+
+func TestSVF(t *testing.T) {
+	tests := []struct {
+		hz   float64
+		resp func(*SVF) Sound
+		want float64
+	}{
+		{100, func(f *SVF) Sound { return f }, 1},
+		{10000, func(f *SVF) Sound { return f }, 0.01},
+		{100, (*SVF).HighPass, 0.01},
+		{10000, (*SVF).HighPass, 1},
+		{1000, (*SVF).BandPass, 1},
+		{1000, (*SVF).Notch, 0},
+	}
+	for i, test := range tests {
+		f := NewSVF(1000, 0.7071, newtone(test.hz))
+		out := test.resp(f)
+		if have := amplitude(out, 64, 64); !equaleps(have, test.want, 0.02) {
+			t.Errorf("tests[%v] have %v, want %v", i, have, test.want)
+		}
+	}
+}
+
+func BenchmarkSVF(b *testing.B) {
+	f := NewSVF(1000, 2, newunit())
+	f.SetFreq(1000, NewLFO(LFOSine, 2))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		f.Prepare(uint64(n))
+	}
+}
@@ -0,0 +1,138 @@
+package snd
+
+import "time"
+
+// DelayTap is a read of TapDelay lines at its own time, gain and pan, with
+// an optional filter.
+type DelayTap struct {
+	vt        [2]vtime
+	gain, fb  float64
+	pan, l, r float64
+	bq        [2]biquad
+	filt      bool
+	dly       *TapDelay
+}
+
+// SetTime moves tap to d, limited to length of delay line. Changes are
+// smoothed over ParamSmoothing.
+func (tap *DelayTap) SetTime(d time.Duration) {
+	for c := range tap.vt {
+		tap.vt[c].SetTime(d, nil)
+	}
+}
+
+func (tap *DelayTap) SetGain(gain float64) { tap.gain = gain }
+
+// SetPan sets position of tap across stereo output where xf belongs to [-1..1].
+func (tap *DelayTap) SetPan(xf float64) {
+	tap.pan = clamp(xf, -1, 1)
+	tap.l, tap.r = getpanfac(tap.pan), getpanfac(-tap.pan)
+}
+
+// SetFeedback sets amount of tap output fed back into delay line.
+func (tap *DelayTap) SetFeedback(fb float64) { tap.fb = fb }
+
+// SetFilter filters output of tap by typ at hz with resonance q.
+func (tap *DelayTap) SetFilter(typ BiquadType, hz, q float64) {
+	sr := tap.dly.SampleRate()
+	for c := range tap.bq {
+		tap.bq[c].set(typ, Hertz(clamp(hz, 1, 0.49*sr)).Normalized(sr), q, 0)
+	}
+	tap.filt = true
+}
+
+// ClearFilter removes filter of tap.
+func (tap *DelayTap) ClearFilter() { tap.filt = false }
+
+// TapDelay is a stereo delay line read by any number of taps.
+//
+// Channels of a stereo input are delayed separately, keeping their image.
+// The delay line is written while off so taps remain in phase when toggled
+// back on. Taps may be added, removed and moved at runtime.
+type TapDelay struct {
+	*stereo
+	lines [2]*bufc
+	max   int
+	taps  []*DelayTap
+
+	wet, dry float64
+}
+
+// NewTapDelay returns TapDelay with taps up to max apart from input.
+func NewTapDelay(max time.Duration, in Sound) *TapDelay {
+	n := Dtof(max, in.SampleRate())
+	lines := [2]*bufc{newbufc(n+2, 0), newbufc(n+2, 0)}
+	return &TapDelay{stereo: newstereo(in), lines: lines, max: n, wet: 1, dry: 1}
+}
+
+// AddTap returns new tap at d with gain and pan.
+func (dly *TapDelay) AddTap(d time.Duration, gain, pan float64) *DelayTap {
+	vt := newvtime(d, dly.max, dly.SampleRate())
+	tap := &DelayTap{vt: [2]vtime{vt, vt}, gain: gain, dly: dly}
+	tap.SetPan(pan)
+	dly.taps = append(dly.taps, tap)
+	return tap
+}
+
+// RemoveTap removes tap from dly.
+func (dly *TapDelay) RemoveTap(tap *DelayTap) {
+	for i, t := range dly.taps {
+		if t == tap {
+			dly.taps = append(dly.taps[:i], dly.taps[i+1:]...)
+			return
+		}
+	}
+}
+
+func (dly *TapDelay) Taps() []*DelayTap { return dly.taps }
+
+// SetMix sets amplitude of taps and original signal.
+func (dly *TapDelay) SetMix(wet, dry float64) { dly.wet, dly.dry = wet, dry }
+
+// read returns output of tap from line of channel c at frame i.
+func (tap *DelayTap) read(c, i int) float64 {
+	line := tap.dly.lines[c]
+	y := tap.vt[c].read(line, line.w, i)
+	if tap.filt {
+		y = tap.bq[c].process(y)
+	}
+	return y * tap.gain
+}
+
+func (dly *TapDelay) Prepare(uint64) {
+	// a mono input is read from the left line only
+	st := dly.in.Channels() == 2
+	for i := range dly.l.out {
+		l, r := frame(dly.in, i)
+
+		var wl, wr, fbl, fbr float64
+		for _, tap := range dly.taps {
+			yl := tap.read(0, i)
+			yr := yl
+			if st {
+				yr = tap.read(1, i)
+			}
+			wl += yl * tap.l
+			wr += yr * tap.r
+			fbl += yl * tap.fb
+			fbr += yr * tap.fb
+		}
+		dly.lines[0].write(l + fbl)
+		if st {
+			dly.lines[1].write(r + fbr)
+		}
+
+		if dly.l.off {
+			dly.l.out[i] = 0
+		} else {
+			dly.l.out[i] = l*dly.dry + wl*dly.wet
+		}
+		if dly.r.off {
+			dly.r.out[i] = 0
+		} else {
+			dly.r.out[i] = r*dly.dry + wr*dly.wet
+		}
+		dly.out[i*2] = dly.l.out[i]
+		dly.out[i*2+1] = dly.r.out[i]
+	}
+}
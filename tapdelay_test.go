@@ -0,0 +1,106 @@
+package snd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTapDelay(t *testing.T) {
+	dly := NewTapDelay(10*time.Millisecond, newimpulse())
+	dly.SetMix(1, 0)
+	dly.AddTap(2*time.Millisecond, 1, -1)
+	tap := dly.AddTap(4*time.Millisecond, 0.5, 1)
+	tap.SetFeedback(0.5)
+	l, r := frames(dly, 2)
+	n1, n2 := Dtof(2*time.Millisecond, dly.SampleRate()), Dtof(4*time.Millisecond, dly.SampleRate())
+	for _, td := range []struct {
+		ch   []float64
+		at   int
+		want float64
+	}{
+		{l, n1, 1}, {r, n1, 0},
+		{r, n2, 0.5}, {l, n2, 0},
+		{r, 2 * n2, 0.125}, // fed back through second tap
+		{l, n1 + n2, 0.25}, // fed back through first tap
+	} {
+		if x := td.ch[td.at]; !equaleps(x, td.want, 0.01) {
+			t.Errorf("have %v at frame %v, want %v", x, td.at, td.want)
+		}
+	}
+}
+
+func TestTapDelayOff(t *testing.T) {
+	// taps of a delay toggled off remain in phase with one never toggled
+	var outs [2][]float64
+	for k := range outs {
+		dly := NewTapDelay(50*time.Millisecond, newtone(440))
+		dly.AddTap(7*time.Millisecond, 1, 0)
+		dly.AddTap(31*time.Millisecond, 1, 0).SetFilter(BiquadLowPass, 1000, 0.7)
+		inps := GetInputs(dly)
+		for tc := 1; tc <= 20; tc++ {
+			if k == 1 && tc == 5 {
+				dly.Off()
+			}
+			if k == 1 && tc == 8 {
+				dly.On()
+			}
+			for _, inp := range inps {
+				inp.sd.Prepare(uint64(tc))
+			}
+			if k == 1 && tc >= 5 && tc < 8 {
+				if !dly.IsOff() {
+					t.Fatalf("have on at tc=%v, want off", tc)
+				}
+				for i, x := range dly.Samples() {
+					if x != 0 {
+						t.Fatalf("have %v while off, want 0 [tc=%v i=%v]", x, tc, i)
+					}
+				}
+			}
+		}
+		outs[k] = append([]float64(nil), dly.Samples()...)
+	}
+	for i := range outs[0] {
+		if !equals(outs[0][i], outs[1][i]) {
+			t.Fatalf("have %v, want %v [i=%v]", outs[1][i], outs[0][i], i)
+		}
+	}
+}
+
+func TestTapDelayStereo(t *testing.T) {
+	// input panned left remains left
+	sd := NewTapDelay(50*time.Millisecond, NewPan(-1, newtone(440)))
+	sd.AddTap(7*time.Millisecond, 1, 0).SetFeedback(0.5)
+	inps := GetInputs(sd)
+	var l float64
+	for tc := 1; tc <= 20; tc++ {
+		for _, inp := range inps {
+			inp.sd.Prepare(uint64(tc))
+		}
+		out := sd.Samples()
+		for i := 0; i < len(out); i += 2 {
+			l += math.Abs(out[i])
+			if out[i+1] != 0 {
+				t.Fatalf("have right %v, want 0 [tc=%v i=%v]", out[i+1], tc, i/2)
+			}
+		}
+	}
+	if l == 0 {
+		t.Fatal("have silent left, want input")
+	}
+}
+
+func BenchmarkTapDelay(b *testing.B) {
+	dly := NewTapDelay(time.Second, newunit())
+	for i := 1; i <= 4; i++ {
+		tap := dly.AddTap(time.Duration(i)*100*time.Millisecond, 0.5, float64(i%2*2-1))
+		tap.SetFeedback(0.2)
+		tap.SetFilter(BiquadLowPass, 2000, 0.7)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		dly.Prepare(uint64(n))
+	}
+}
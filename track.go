@@ -0,0 +1,40 @@
+package snd
+
+import "math"
+
+// Velocity is the strike strength of a note belonging to [0..1].
+type Velocity float64
+
+// Amp returns amplitude multiplier of vel where rng is the attenuation in
+// decibels at zero velocity and full velocity is unity.
+func (vel Velocity) Amp(rng Decibel) float64 {
+	return Decibel(-float64(rng) * (1 - float64(vel))).Amp()
+}
+
+// Scale returns a time multiplier of vel that is unity at half velocity and
+// shortens by amt octaves at full velocity, or lengthens by amt octaves at zero.
+func (vel Velocity) Scale(amt float64) float64 {
+	return math.Exp2(-amt * (2*float64(vel) - 1))
+}
+
+// KeyScale returns a time multiplier that is unity at key center and shortens
+// by amt octaves for every octave key is above center, or lengthens below.
+func KeyScale(key, center int, amt float64) float64 {
+	return math.Exp2(-amt * float64(key-center) / 12)
+}
+
+// Tracking scales an envelope by note velocity and key number.
+//
+// The zero value disables tracking. Tracking applies to GateADSR only; ADSR
+// and Breakpoints are not tracked but may be scaled by Gain.SetVelocity.
+type Tracking struct {
+	// VelAmp is attenuation of peak and sustain levels at zero velocity.
+	VelAmp Decibel
+
+	// VelAttack is octaves attack time shortens at full velocity; see Velocity.Scale.
+	VelAttack float64
+
+	// KeyDecay is octaves decay time shortens per octave above KeyCenter; see KeyScale.
+	KeyDecay  float64
+	KeyCenter int
+}
@@ -0,0 +1,74 @@
+package snd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVelocity(t *testing.T) {
+	tests := []struct {
+		have, want float64
+	}{
+		{Velocity(1).Amp(40), 1},
+		{Velocity(0).Amp(40), 0.01},
+		{Velocity(0.5).Amp(0), 1},
+		{Velocity(1).Scale(1), 0.5},
+		{Velocity(0.5).Scale(1), 1},
+		{Velocity(0).Scale(2), 4},
+		{KeyScale(72, 60, 1), 0.5},
+		{KeyScale(48, 60, 0.5), 1.4142},
+	}
+	for i, test := range tests {
+		if !equals(test.have, test.want) {
+			t.Errorf("tests[%v] have %v, want %v", i, test.have, test.want)
+		}
+	}
+}
+
+func TestGateADSRTracking(t *testing.T) {
+	ms := time.Millisecond
+	env := NewGateADSR(ms, ms, ms, 0.5, 1, nil)
+	env.SetTracking(Tracking{VelAmp: 20})
+	env.NoteOn(60, 0)
+	for n := 1; n <= 4; n++ {
+		env.Prepare(uint64(n))
+	}
+	if x := env.Level(); !equals(x, 0.05) {
+		t.Fatalf("have sustain %v, want 0.05", x)
+	}
+}
+
+func TestGateADSRSetNote(t *testing.T) {
+	ms := time.Millisecond
+	env := NewGateADSR(ms, ms, ms, 0.5, 1, nil)
+	env.SetTracking(Tracking{VelAmp: 20})
+	env.NoteOn(60, 1)
+	for n := 1; n <= 4; n++ {
+		env.Prepare(uint64(n))
+	}
+
+	// sounding note is unchanged until next gate-on
+	env.SetNote(60, 0)
+	env.Prepare(5)
+	if x := env.Level(); !equals(x, 0.5) {
+		t.Fatalf("have sustain %v after SetNote, want 0.5", x)
+	}
+	env.GateOff()
+	env.Prepare(6)
+	env.GateOn()
+	for n := 7; n <= 10; n++ {
+		env.Prepare(uint64(n))
+	}
+	if x := env.Level(); !equals(x, 0.05) {
+		t.Fatalf("have sustain %v after gate-on, want 0.05", x)
+	}
+}
+
+func TestGainVelocity(t *testing.T) {
+	gn := NewGain(1, newunit())
+	gn.SetVelocity(0.5, 12)
+	gn.Prepare(1)
+	if x, want := gn.Samples()[0], DefaultAmpFac*Decibel(-6).Amp(); !equals(x, want) {
+		t.Fatalf("have %v, want %v", x, want)
+	}
+}
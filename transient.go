@@ -0,0 +1,81 @@
+package snd
+
+import (
+	"math"
+	"time"
+)
+
+// transientSpan is the difference of envelopes in decibels at which
+// TransientShaper applies full attack or sustain gain.
+const transientSpan = 12
+
+// TransientShaper emphasizes or softens attack and sustain of a signal
+// independent of its level.
+//
+// Attack is detected where an envelope with fast attack rises above one
+// following it with slow attack, and sustain where an envelope following one
+// with fast release remains above it with slow release. Detection of both
+// channels is linked.
+type TransientShaper struct {
+	*stereo
+	attack, sustain float64 // decibels
+
+	// envelopes detecting attack and sustain
+	fastatk, slowatk follower
+	fastrel, slowrel follower
+
+	gr *gainout
+}
+
+// NewTransientShaper returns TransientShaper of in with no change to attack or sustain.
+func NewTransientShaper(in Sound) *TransientShaper {
+	sr := in.SampleRate()
+	ts := &TransientShaper{
+		stereo:  newstereo(in),
+		fastatk: newfollower(time.Millisecond, 100*time.Millisecond, sr),
+		slowatk: newfollower(20*time.Millisecond, 100*time.Millisecond, sr),
+		fastrel: newfollower(time.Millisecond, 50*time.Millisecond, sr),
+		slowrel: newfollower(time.Millisecond, 200*time.Millisecond, sr),
+	}
+	ts.gr = &gainout{newmono(ts)}
+	return ts
+}
+
+// SetAttack sets gain applied to attack of transients, negative to soften.
+func (ts *TransientShaper) SetAttack(db Decibel) { ts.attack = float64(db) }
+
+// SetSustain sets gain applied to sustain following transients, negative to shorten.
+func (ts *TransientShaper) SetSustain(db Decibel) { ts.sustain = float64(db) }
+
+// Gain returns gain applied as a Sound, suitable as a modulation source.
+func (ts *TransientShaper) Gain() Sound { return ts.gr }
+
+// dbspan returns difference in decibels of amplitudes a and b as a portion of transientSpan.
+func dbspan(a, b float64) float64 {
+	d := 20 * math.Log10(math.Max(a, 1e-10)/math.Max(b, 1e-10))
+	return clamp(d/transientSpan, 0, 1)
+}
+
+func (ts *TransientShaper) Prepare(uint64) {
+	for i := range ts.l.out {
+		l, r := frame(ts.in, i)
+		x := math.Max(math.Abs(l), math.Abs(r))
+		fa, fr := ts.fastatk.next(x), ts.fastrel.next(x)
+		atk := dbspan(fa, ts.slowatk.next(fa))
+		sus := dbspan(ts.slowrel.next(fr), fr)
+		g := Decibel(ts.attack*atk + ts.sustain*sus).Amp()
+		ts.gr.out[i] = g
+		if ts.l.off {
+			ts.l.out[i] = 0
+		} else {
+			ts.l.out[i] = l * g
+		}
+		if ts.r.off {
+			ts.r.out[i] = 0
+		} else {
+			ts.r.out[i] = r * g
+		}
+		ts.out[i*2] = ts.l.out[i]
+		ts.out[i*2+1] = ts.r.out[i]
+	}
+}
@@ -0,0 +1,72 @@
+package snd
+
+import (
+	"math"
+	"testing"
+)
+
+// decay is a sine of unit amplitude decaying exponentially by tau frames.
+type decay struct {
+	*tone
+	tau float64
+	n   int
+}
+
+func (dc *decay) Prepare(tc uint64) {
+	dc.tone.Prepare(tc)
+	for i := range dc.out {
+		dc.out[i] *= math.Exp(-float64(dc.n) / dc.tau)
+		dc.n++
+	}
+}
+
+// gains returns greatest and least gain of ts over buffers from, to.
+func gains(ts *TransientShaper, from, to int) (max, min float64) {
+	max, min = 0, math.Inf(1)
+	for tc := 1; tc <= to; tc++ {
+		ts.in.Prepare(uint64(tc))
+		ts.Prepare(uint64(tc))
+		if tc < from {
+			continue
+		}
+		for _, g := range ts.Gain().Samples() {
+			max, min = math.Max(max, g), math.Min(min, g)
+		}
+	}
+	return max, min
+}
+
+func TestTransientShaperAttack(t *testing.T) {
+	for _, db := range []Decibel{12, -12} {
+		ts := NewTransientShaper(newtone(440))
+		ts.SetAttack(db)
+		max, min := gains(ts, 1, 1)
+		if want := db.Amp(); !equaleps(max, want, 0.01) && !equaleps(min, want, 0.01) {
+			t.Errorf("attack %v have gain [%v, %v] at onset, want %v", db, min, max, want)
+		}
+		// steady tone is left unchanged
+		if max, min := gains(ts, 20, 40); !equaleps(max, 1, 0.01) || !equaleps(min, 1, 0.01) {
+			t.Errorf("attack %v have gain [%v, %v] after onset, want 1", db, min, max)
+		}
+	}
+}
+
+func TestTransientShaperSustain(t *testing.T) {
+	ts := NewTransientShaper(&decay{tone: newtone(440), tau: 2400})
+	ts.SetSustain(-12)
+	max, min := gains(ts, 20, 40)
+	if want := Decibel(-12).Amp(); !equaleps(min, want, 0.01) || max > Decibel(-6).Amp() {
+		t.Fatalf("have gain [%v, %v] during decay, want %v", min, max, want)
+	}
+}
+
+func BenchmarkTransientShaper(b *testing.B) {
+	ts := NewTransientShaper(newunit())
+	ts.SetAttack(6)
+	ts.SetSustain(-6)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ts.Prepare(uint64(n))
+	}
+}
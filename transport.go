@@ -0,0 +1,104 @@
+package snd
+
+import "math"
+
+// Quantize is the musical boundary state changes are delayed to.
+type Quantize int
+
+const (
+	QuantizeNone Quantize = iota
+	QuantizeBeat
+	QuantizeBar
+)
+
+// Transport is a musical clock counting frames from its origin, shared by
+// sounds that must remain in phase.
+//
+// Output is phase of the current beat belonging to [0..1).
+type Transport struct {
+	*mono
+	bpm   BPM
+	meter int // beats per bar
+
+	// frame at start of current buffer and start of next
+	frame, next uint64
+
+	// frame of last change of tempo and beats elapsed at it
+	anchor uint64
+	beats  float64
+}
+
+// NewTransport returns Transport at bpm with meter beats per bar.
+func NewTransport(bpm BPM, meter int) *Transport {
+	tp := &Transport{mono: newmono(nil), bpm: bpm}
+	tp.SetMeter(meter)
+	return tp
+}
+
+// SetBPM sets tempo from the next buffer prepared; beats elapsed are unchanged.
+func (tp *Transport) SetBPM(bpm BPM) {
+	tp.beats, tp.anchor = tp.beatat(tp.next), tp.next
+	tp.bpm = bpm
+}
+
+func (tp *Transport) BPM() BPM { return tp.bpm }
+
+// SetMeter sets number of beats per bar.
+func (tp *Transport) SetMeter(meter int) {
+	if meter < 1 {
+		meter = 1
+	}
+	tp.meter = meter
+}
+
+// Frame returns frame at start of current buffer.
+func (tp *Transport) Frame() uint64 { return tp.frame }
+
+// Beat returns number of beats elapsed at start of current buffer.
+func (tp *Transport) Beat() float64 { return tp.beatat(tp.frame) }
+
+// beatat returns number of beats elapsed at frame f at current tempo.
+func (tp *Transport) beatat(f uint64) float64 {
+	return tp.beats + (float64(f)-float64(tp.anchor))/tp.beatlen()
+}
+
+// beatlen returns length of a beat in frames.
+func (tp *Transport) beatlen() float64 { return 60 / float64(tp.bpm) * tp.sr }
+
+// Next returns first frame not yet prepared that falls on boundary q.
+func (tp *Transport) Next(q Quantize) uint64 {
+	var n float64 // beats
+	switch q {
+	case QuantizeBeat:
+		n = 1
+	case QuantizeBar:
+		n = float64(tp.meter)
+	default:
+		return tp.next
+	}
+	// frame at beat b rounded to nearest
+	at := func(b float64) uint64 {
+		return uint64(math.Round(float64(tp.anchor) + (b-tp.beats)*tp.beatlen()))
+	}
+	k := math.Ceil(tp.beatat(tp.next)/n) * n
+	f := at(k)
+	if f < tp.next {
+		f = at(k + n)
+	}
+	return f
+}
+
+func (tp *Transport) Inputs() []Sound { return nil }
+
+func (tp *Transport) Prepare(uint64) {
+	tp.frame = tp.next
+	for i := range tp.out {
+		if tp.off {
+			tp.out[i] = 0
+		} else {
+			b := tp.beatat(tp.frame + uint64(i))
+			tp.out[i] = b - math.Floor(b)
+		}
+	}
+	tp.next += uint64(len(tp.out))
+}
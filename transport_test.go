@@ -0,0 +1,60 @@
+package snd
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransportNext(t *testing.T) {
+	tp := NewTransport(120, 4)
+	beat := uint64(DefaultSampleRate / 2)
+	if f := tp.Next(QuantizeBeat); f != 0 {
+		t.Fatalf("next beat at origin have %v, want 0", f)
+	}
+	tp.Prepare(1)
+	for _, td := range []struct {
+		q    Quantize
+		want uint64
+	}{
+		{QuantizeNone, DefaultBufferLen},
+		{QuantizeBeat, beat},
+		{QuantizeBar, 4 * beat},
+	} {
+		if f := tp.Next(td.q); f != td.want {
+			t.Errorf("quantize %v have %v, want %v", td.q, f, td.want)
+		}
+	}
+	if x := tp.Index(DefaultBufferLen - 1); !equals(x, float64(DefaultBufferLen-1)/float64(beat)) {
+		t.Errorf("beat phase have %v", x)
+	}
+}
+
+func TestTransportTempo(t *testing.T) {
+	tp := NewTransport(120, 4)
+	for tc := 1; tc <= 10; tc++ {
+		tp.Prepare(uint64(tc))
+	}
+	b := tp.Beat()
+	next := tp.next
+
+	// beats continue from where tempo changed
+	tp.SetBPM(60)
+	tp.Prepare(11)
+	beat := DefaultSampleRate
+	if have, want := tp.Beat(), b+float64(DefaultBufferLen)/(DefaultSampleRate/2); !equals(have, want) {
+		t.Fatalf("have beat %v, want %v", have, want)
+	}
+	if x, want := tp.Index(0), b+float64(DefaultBufferLen)/(DefaultSampleRate/2); !equals(x, want-math.Floor(want)) {
+		t.Fatalf("have beat phase %v, want %v", x, want-math.Floor(want))
+	}
+
+	// next beat falls on grid of new tempo from where it changed
+	f := tp.Next(QuantizeBeat)
+	want := next + uint64(math.Round((math.Ceil(tp.beatat(tp.next))-tp.beatat(next))*beat))
+	if f != want {
+		t.Fatalf("have next beat %v, want %v", f, want)
+	}
+	if x := tp.beatat(f); !equaleps(x, math.Round(x), 1e-4) {
+		t.Fatalf("next beat at %v beats", x)
+	}
+}
@@ -0,0 +1,118 @@
+package snd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"dasa.cc/signal"
+)
+
+// DecodeWAV reads a RIFF WAVE stream of integer PCM or floating point samples,
+// returning each channel deinterleaved and the sample rate.
+func DecodeWAV(r io.Reader) (chans []signal.Discrete, sr float64, err error) {
+	var hdr [12]byte
+	if _, err = io.ReadFull(r, hdr[:]); err != nil {
+		return nil, 0, err
+	}
+	if string(hdr[0:4]) != "RIFF" || string(hdr[8:12]) != "WAVE" {
+		return nil, 0, fmt.Errorf("snd: not a RIFF WAVE stream")
+	}
+
+	// chunks are bounded by what remains of the RIFF chunk after its form type
+	remain := int64(binary.LittleEndian.Uint32(hdr[4:8])) - 4
+
+	var (
+		format, nchans, bits uint16
+		havefmt              bool
+	)
+	for {
+		var ck [8]byte
+		if _, err = io.ReadFull(r, ck[:]); err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("snd: WAVE stream has no data chunk")
+			}
+			return nil, 0, err
+		}
+		id, size := string(ck[0:4]), binary.LittleEndian.Uint32(ck[4:8])
+		if remain -= 8; int64(size) > remain {
+			return nil, 0, fmt.Errorf("snd: WAVE %q chunk exceeds RIFF chunk", id)
+		}
+		// read rather than allocate size up front so a corrupt size can not
+		// exhaust memory; chunks are padded to even length
+		n := int64(size) + int64(size&1)
+		if n > remain {
+			n = remain
+		}
+		var body []byte
+		if body, err = io.ReadAll(io.LimitReader(r, n)); err != nil {
+			return nil, 0, err
+		}
+		remain -= int64(len(body))
+		if int64(len(body)) < n && (id != "data" || len(body) < int(size)) {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		// tolerate missing pad byte at end of stream
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, 0, fmt.Errorf("snd: WAVE fmt chunk too short")
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			nchans = binary.LittleEndian.Uint16(body[2:4])
+			sr = float64(binary.LittleEndian.Uint32(body[4:8]))
+			bits = binary.LittleEndian.Uint16(body[14:16])
+			if format == 0xfffe && size >= 26 { // WAVE_FORMAT_EXTENSIBLE
+				format = binary.LittleEndian.Uint16(body[24:26])
+			}
+			havefmt = true
+		case "data":
+			if !havefmt {
+				return nil, 0, fmt.Errorf("snd: WAVE data chunk precedes fmt chunk")
+			}
+			return decodewavdata(body, format, int(nchans), int(bits), sr)
+		}
+	}
+}
+
+func decodewavdata(body []byte, format uint16, nchans, bits int, sr float64) ([]signal.Discrete, float64, error) {
+	if nchans == 0 {
+		return nil, 0, fmt.Errorf("snd: WAVE stream has no channels")
+	}
+	size := bits / 8
+	var sample func(b []byte) float64
+	switch {
+	case format == 1 && bits == 8:
+		sample = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format == 1 && bits == 16:
+		sample = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }
+	case format == 1 && bits == 24:
+		sample = func(b []byte) float64 {
+			return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / (1 << 23)
+		}
+	case format == 1 && bits == 32:
+		sample = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case format == 3 && bits == 32:
+		sample = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	case format == 3 && bits == 64:
+		sample = func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }
+	default:
+		return nil, 0, fmt.Errorf("snd: unsupported WAVE format %v with %v bits", format, bits)
+	}
+
+	n := len(body) / (size * nchans)
+	chans := make([]signal.Discrete, nchans)
+	for c := range chans {
+		chans[c] = make(signal.Discrete, n)
+	}
+	for i := 0; i < n; i++ {
+		for c := range chans {
+			off := (i*nchans + c) * size
+			chans[c][i] = sample(body[off : off+size])
+		}
+	}
+	return chans, sr, nil
+}
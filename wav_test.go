@@ -0,0 +1,90 @@
+package snd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// mkwav returns a WAVE stream of interleaved samples encoded by put.
+func mkwav(format, nchans, bits uint16, sr uint32, xs []float64, put func([]byte, float64)) []byte {
+	size := int(bits / 8)
+	data := make([]byte, len(xs)*size)
+	for i, x := range xs {
+		put(data[i*size:], x)
+	}
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.WriteString("RIFF")
+	binary.Write(&buf, le, uint32(4+8+16+8+len(data)))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, le, uint32(16))
+	binary.Write(&buf, le, format)
+	binary.Write(&buf, le, nchans)
+	binary.Write(&buf, le, sr)
+	binary.Write(&buf, le, sr*uint32(nchans)*uint32(size))
+	binary.Write(&buf, le, nchans*uint16(size))
+	binary.Write(&buf, le, bits)
+	buf.WriteString("data")
+	binary.Write(&buf, le, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	xs := []float64{0.5, -0.5, 0.25, -0.25, 0, 1}
+
+	b := mkwav(1, 2, 16, 44100, xs, func(b []byte, x float64) {
+		binary.LittleEndian.PutUint16(b, uint16(int16(math.Min(x*(1<<15), math.MaxInt16))))
+	})
+	chans, sr, err := DecodeWAV(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr != 44100 || len(chans) != 2 || len(chans[0]) != 3 {
+		t.Fatalf("have sr %v with %v channels", sr, len(chans))
+	}
+	for i, x := range xs {
+		if have := chans[i%2][i/2]; !equaleps(have, x, 0.001) {
+			t.Errorf("pcm16 sample %v have %v, want %v", i, have, x)
+		}
+	}
+
+	b = mkwav(3, 1, 32, 48000, xs, func(b []byte, x float64) {
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(x)))
+	})
+	chans, _, err = DecodeWAV(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range xs {
+		if have := chans[0][i]; !equals(have, x) {
+			t.Errorf("float32 sample %v have %v, want %v", i, have, x)
+		}
+	}
+
+	if _, _, err := DecodeWAV(bytes.NewReader([]byte("RIFF0000AVI "))); err == nil {
+		t.Error("expected error decoding non-WAVE stream")
+	}
+}
+
+func TestDecodeWAVSize(t *testing.T) {
+	put := func(b []byte, x float64) { b[0] = byte(x*127 + 128) }
+	le := binary.LittleEndian
+	for _, td := range []struct {
+		riff, data uint32
+	}{
+		{0, 0},                    // RIFF chunk too short for its chunks
+		{math.MaxUint32, 1 << 31}, // data chunk larger than stream
+		{math.MaxUint32, 100},
+	} {
+		b := mkwav(1, 1, 8, 44100, []float64{0, 0.5, -0.5}, put)
+		le.PutUint32(b[4:], td.riff)
+		le.PutUint32(b[40:], td.data)
+		if _, _, err := DecodeWAV(bytes.NewReader(b)); err == nil {
+			t.Errorf("RIFF size %v with data size %v expected error", td.riff, td.data)
+		}
+	}
+}